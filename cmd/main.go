@@ -1,60 +1,376 @@
 package main
 
 import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"flag"
+	"fmt"
 	"net"
+	"os"
+	"os/signal"
+	"syscall"
+	"time"
 
+	"github.com/PharmaKart/reminder-svc/internal/auth"
+	"github.com/PharmaKart/reminder-svc/internal/dispatcher"
+	"github.com/PharmaKart/reminder-svc/internal/encryption"
+	"github.com/PharmaKart/reminder-svc/internal/eventbus"
+	"github.com/PharmaKart/reminder-svc/internal/events"
+	"github.com/PharmaKart/reminder-svc/internal/gateway"
 	"github.com/PharmaKart/reminder-svc/internal/handlers"
+	"github.com/PharmaKart/reminder-svc/internal/health"
+	"github.com/PharmaKart/reminder-svc/internal/metrics"
+	"github.com/PharmaKart/reminder-svc/internal/migrations"
 	"github.com/PharmaKart/reminder-svc/internal/proto"
 	"github.com/PharmaKart/reminder-svc/internal/repositories"
+	"github.com/PharmaKart/reminder-svc/internal/server"
+	"github.com/PharmaKart/reminder-svc/internal/services"
+	"github.com/PharmaKart/reminder-svc/internal/tracing"
 	"github.com/PharmaKart/reminder-svc/pkg/config"
 	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
 	"google.golang.org/grpc"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/reflection"
 )
 
 func main() {
-	// Initialize logger
-	utils.InitLogger()
+	// "config check" validates and prints the configuration without starting
+	if len(os.Args) > 2 && os.Args[1] == "config" && os.Args[2] == "check" {
+		os.Exit(checkConfig(os.Args[3:]))
+	}
 
 	// Load configuration
-	cfg := config.LoadConfig()
+	cfg, err := config.Load(os.Args[1:])
+	if errors.Is(err, flag.ErrHelp) {
+		return
+	}
+	if err != nil {
+		utils.Fatal("Invalid configuration", map[string]interface{}{
+			"error": err,
+		})
+	}
+
+	// Initialize logger
+	if err := utils.InitLogger(cfg); err != nil {
+		utils.Fatal("Invalid logging configuration", map[string]interface{}{
+			"error": err,
+		})
+	}
+
+	// Cancelled on SIGINT or SIGTERM to start a graceful shutdown
+	ctx, stop := signal.NotifyContext(context.Background(), os.Interrupt, syscall.SIGTERM)
+	defer stop()
 
 	// Initialize database connection
-	db, err := utils.ConnectDB(cfg)
+	db, err := utils.ConnectDB(ctx, cfg)
 	if err != nil {
-		utils.Logger.Fatal("Failed to connect to database", map[string]interface{}{
+		utils.Fatal("Failed to connect to database", map[string]interface{}{
 			"error": err,
 		})
 	}
 
+	// Export traces for RPCs, queries and dispatched messages
+	shutdownTracing, err := tracing.Init(ctx, cfg)
+	if err != nil {
+		utils.Fatal("Failed to initialize tracing", map[string]interface{}{
+			"error": err,
+		})
+	}
+
+	if err := tracing.InstrumentDB(db); err != nil {
+		utils.Fatal("Failed to instrument database for tracing", map[string]interface{}{
+			"error": err,
+		})
+	}
+
+	// Time every query for the metrics endpoint
+	if err := metrics.InstrumentDB(db); err != nil {
+		utils.Fatal("Failed to instrument database", map[string]interface{}{
+			"error": err,
+		})
+	}
+
+	// Report readiness and liveness through the standard gRPC health service
+	healthChecker := health.NewChecker(db, cfg.HEALTH_CHECK_INTERVAL, proto.ReminderService_ServiceDesc.ServiceName)
+
+	// Create or update this service's tables
+	if err := migrations.Run(db); err != nil {
+		utils.Fatal("Failed to migrate database", map[string]interface{}{
+			"error": err,
+		})
+	}
+	healthChecker.SetMigrated()
+
 	// Initialize repositories
 	reminderRepo := repositories.NewReminderRepository(db)
 	reminderLogRepo := repositories.NewReminderLogRepository(db)
+	outboxRepo := repositories.NewOutboxRepository(db)
+	suppressionRepo := repositories.NewSuppressionRepository(db)
+	consentRepo := repositories.NewConsentRepository(db)
+	preferenceRepo := repositories.NewPreferenceRepository(db)
+	auditRepo := repositories.NewAuditRepository(db)
+	transactor := repositories.NewTransactor(db)
+
+	// Initialize the outgoing message dispatcher
+	reminderDispatcher, err := dispatcher.NewDispatcher(context.Background(), cfg)
+	if err != nil {
+		utils.Fatal("Failed to initialize dispatcher", map[string]interface{}{
+			"error": err,
+		})
+	}
+
+	reminderDispatcher, err = dispatcher.NewRateLimitedDispatcher(reminderDispatcher, cfg)
+	if err != nil {
+		utils.Fatal("Invalid rate limit configuration", map[string]interface{}{
+			"error": err,
+		})
+	}
+
+	retryPolicy := services.NewRetryPolicy(cfg)
+
+	// Encrypts contact details stored in outbox payloads and delivery events
+	cipher, err := encryption.NewCipher(cfg)
+	if err != nil {
+		utils.Fatal("Invalid encryption configuration", map[string]interface{}{
+			"error": err,
+		})
+	}
+	if !cipher.Enabled() {
+		utils.Warn("ENCRYPTION_KEYS is not set, contact details are stored unencrypted", nil)
+	}
+
+	// Pushes reminder changes to WatchReminderEvents streams
+	reminderEvents, err := eventbus.NewBus(cfg, db)
+	if err != nil {
+		utils.Fatal("Invalid reminder events bus configuration", map[string]interface{}{
+			"error": err,
+		})
+	}
+	go func() {
+		if err := reminderEvents.Start(ctx); err != nil {
+			utils.Error("Reminder events bus stopped", map[string]interface{}{
+				"error": err,
+			})
+		}
+	}()
 
-	// Initialize handlers
-	reminderHandler := handlers.NewReminderHandler(reminderRepo, reminderLogRepo)
+	// Initialize handlers. Order events go through the same reminder service as RPCs.
+	reminderService := services.NewReminderService(reminderRepo, reminderLogRepo, outboxRepo, suppressionRepo, consentRepo, preferenceRepo, auditRepo, transactor, retryPolicy, cipher, reminderEvents)
+	reminderHandler := handlers.NewReminderHandler(cfg, reminderService, outboxRepo, suppressionRepo, consentRepo, preferenceRepo, auditRepo, transactor, reminderDispatcher, retryPolicy, cipher, reminderEvents)
 
-	// Cron job to send reminders
-	go reminderHandler.StartReminderService(cfg)
+	// Cron job to send reminders. Jobs are cancelled if they outlive the shutdown timeout.
+	jobCtx, cancelJobs := context.WithCancel(context.Background())
+	defer cancelJobs()
+	scheduler := reminderHandler.StartReminderService(jobCtx, cfg)
+
+	// The heartbeat job shows the scheduler is still running
+	healthChecker.Heartbeat()
+	if _, err := scheduler.AddFunc(fmt.Sprintf("@every %s", cfg.HEALTH_CHECK_INTERVAL/2), healthChecker.Heartbeat); err != nil {
+		utils.Fatal("Failed to schedule health heartbeat", map[string]interface{}{
+			"error": err,
+		})
+	}
+	go healthChecker.Start(ctx)
+
+	// Consume order-service events to schedule reminders
+	consumer, err := events.NewConsumer(ctx, cfg)
+	if err != nil {
+		utils.Fatal("Failed to initialize event consumer", map[string]interface{}{
+			"error": err,
+		})
+	}
+
+	if consumer != nil {
+		orderEventHandler := events.NewOrderEventHandler(reminderService)
+		go func() {
+			if err := consumer.Start(ctx, orderEventHandler); err != nil {
+				utils.Error("Event consumer stopped", map[string]interface{}{
+					"error": err,
+				})
+			}
+		}()
+
+		utils.Info("Consuming order events", map[string]interface{}{
+			"source": cfg.EVENT_SOURCE,
+		})
+	}
+
+	// Expose Prometheus metrics
+	if cfg.METRICS_PORT != "" {
+		go func() {
+			if err := metrics.Serve(ctx, ":"+cfg.METRICS_PORT); err != nil {
+				utils.Error("Metrics server stopped", map[string]interface{}{
+					"error": err,
+				})
+			}
+		}()
+	}
+
+	// Serve TLS, and require client certificates for mTLS, when configured
+	certs, err := server.LoadTLS(cfg)
+	if err != nil {
+		utils.Fatal("Failed to load TLS certificate", map[string]interface{}{
+			"error": err,
+		})
+	}
+	if certs != nil {
+		go certs.Start(ctx, cfg.GRPC_TLS_RELOAD_INTERVAL)
+	} else {
+		utils.Warn("GRPC_TLS_CERT_FILE is not set, serving gRPC without TLS", nil)
+	}
+
+	// Callers are identified by bearer tokens signed with AUTH_TOKEN_SECRET,
+	// or by their client certificate with mTLS. The gateway presents the
+	// server certificate, which identifies no one.
+	var tokens *auth.TokenVerifier
+	if cfg.AUTH_TOKEN_SECRET != "" {
+		tokens = auth.NewTokenVerifier(cfg.AUTH_TOKEN_SECRET)
+	} else {
+		utils.Warn("AUTH_TOKEN_SECRET is not set, bearer tokens are rejected", nil)
+	}
+	var loopback func(*x509.Certificate) bool
+	if certs != nil {
+		loopback = certs.IsServerCertificate
+	}
+	authenticator := auth.NewAuthenticator(tokens, loopback)
 
 	// Initialize gRPC server
 	lis, err := net.Listen("tcp", ":"+cfg.Port)
 
 	if err != nil {
-		utils.Logger.Fatal("Failed to listen", map[string]interface{}{
+		utils.Fatal("Failed to listen", map[string]interface{}{
 			"error": err,
 		})
 	}
 
-	grpcServer := grpc.NewServer()
+	serverOpts := append(server.ServerOptions(cfg, certs),
+		// Continues the caller's trace when the request carries one
+		grpc.StatsHandler(otelgrpc.NewServerHandler()),
+		grpc.ChainUnaryInterceptor(
+			handlers.MetricsInterceptor(),
+			handlers.PrincipalInterceptor(authenticator),
+			handlers.LoggingInterceptor(),
+			handlers.TimeoutInterceptor(cfg.RPC_TIMEOUT),
+		),
+		// Streams are long-lived, so they get no timeout
+		grpc.ChainStreamInterceptor(
+			handlers.MetricsStreamInterceptor(),
+			handlers.PrincipalStreamInterceptor(authenticator),
+			handlers.LoggingStreamInterceptor(),
+		),
+	)
+	grpcServer := grpc.NewServer(serverOpts...)
 	proto.RegisterReminderServiceServer(grpcServer, reminderHandler)
+	healthpb.RegisterHealthServer(grpcServer, healthChecker.Server())
+
+	// Lets tools like grpcurl discover the API
+	if cfg.GRPC_REFLECTION {
+		reflection.Register(grpcServer)
+	}
 
 	utils.Info("Starting reminder service", map[string]interface{}{
 		"port": cfg.Port,
+		"tls":  certs != nil,
+		"mtls": cfg.GRPC_TLS_CLIENT_CA_FILE != "",
 	})
 
-	if err := grpcServer.Serve(lis); err != nil {
-		utils.Logger.Fatal("Failed to serve", map[string]interface{}{
+	// Serve the API as JSON over HTTP, forwarding to the gRPC server
+	if cfg.GATEWAY_PORT != "" {
+		go func() {
+			if err := gateway.Serve(ctx, ":"+cfg.GATEWAY_PORT, "localhost:"+cfg.Port, cfg.GATEWAY_WATCH_CONNS, server.DialOptions(cfg, certs)...); err != nil {
+				utils.Error("HTTP gateway stopped", map[string]interface{}{
+					"error": err,
+				})
+			}
+		}()
+
+		utils.Info("Serving HTTP gateway", map[string]interface{}{
+			"port": cfg.GATEWAY_PORT,
+		})
+	}
+
+	serveErr := make(chan error, 1)
+	go func() {
+		serveErr <- grpcServer.Serve(lis)
+	}()
+
+	select {
+	case err := <-serveErr:
+		utils.Fatal("Failed to serve", map[string]interface{}{
+			"error": err,
+		})
+	case <-ctx.Done():
+	}
+
+	utils.Info("Shutting down reminder service", map[string]interface{}{
+		"timeout": cfg.SHUTDOWN_TIMEOUT.String(),
+	})
+	deadline := time.Now().Add(cfg.SHUTDOWN_TIMEOUT)
+
+	// Fail health checks so no new traffic is routed here
+	healthChecker.Shutdown()
+
+	// Drain in-flight RPCs, forcing the remaining ones closed at the deadline
+	stopped := make(chan struct{})
+	go func() {
+		grpcServer.GracefulStop()
+		close(stopped)
+	}()
+
+	select {
+	case <-stopped:
+	case <-time.After(time.Until(deadline)):
+		utils.Warn("Timed out draining RPCs", nil)
+		grpcServer.Stop()
+	}
+
+	// Stop scheduling jobs and wait for a running one to finish
+	jobsDone := scheduler.Stop().Done()
+	select {
+	case <-jobsDone:
+	case <-time.After(time.Until(deadline)):
+		utils.Warn("Timed out waiting for running reminder job, cancelling it", nil)
+		cancelJobs()
+		<-jobsDone
+	}
+
+	if sqlDB, err := db.DB(); err == nil {
+		if err := sqlDB.Close(); err != nil {
+			utils.Error("Failed to close database", map[string]interface{}{
+				"error": err,
+			})
+		}
+	}
+
+	// Flush pending spans
+	flushCtx, cancelFlush := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancelFlush()
+	if err := shutdownTracing(flushCtx); err != nil {
+		utils.Error("Failed to flush traces", map[string]interface{}{
 			"error": err,
 		})
 	}
+
+	utils.Info("Reminder service stopped", nil)
+}
+
+// checkConfig loads the configuration from args and prints it with secrets
+// redacted, returning the exit code
+func checkConfig(args []string) int {
+	cfg, err := config.Load(args)
+	if errors.Is(err, flag.ErrHelp) {
+		return 0
+	}
+	if err != nil {
+		fmt.Fprintf(os.Stderr, "Invalid configuration:\n%v\n", err)
+		return 1
+	}
+
+	if err := cfg.Print(os.Stdout); err != nil {
+		fmt.Fprintf(os.Stderr, "Failed to print configuration: %v\n", err)
+		return 1
+	}
+	return 0
 }
@@ -0,0 +1,151 @@
+package auth
+
+import (
+	"context"
+	"crypto/x509"
+	"errors"
+	"strings"
+
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+)
+
+// AuthorizationHeader carries the caller's bearer token
+const AuthorizationHeader = "authorization"
+
+// Metadata keys that used to identify the caller. They are not trusted, and
+// calls that carry them without verified credentials are rejected.
+const (
+	UserIDHeader   = "x-user-id"
+	UserRoleHeader = "x-user-role"
+)
+
+const (
+	RoleCustomer = "customer"
+	RoleAdmin    = "admin"
+	RoleService  = "service"
+)
+
+// Principal is the caller of an RPC
+type Principal struct {
+	ID   string
+	Role string
+}
+
+// String identifies the principal in logs and audit records
+func (p Principal) String() string {
+	if p.ID == "" && p.Role == "" {
+		return "anonymous"
+	}
+	if p.ID == "" {
+		return p.Role
+	}
+	return p.Role + ":" + p.ID
+}
+
+func (p Principal) IsAdmin() bool {
+	return p.Role == RoleAdmin
+}
+
+func (p Principal) IsService() bool {
+	return p.Role == RoleService
+}
+
+// ErrUnverifiedIdentity is returned for calls that name a caller in the
+// x-user-id or x-user-role metadata without verified credentials
+var ErrUnverifiedIdentity = errors.New("x-user-id and x-user-role are not accepted, use a bearer token")
+
+// ErrTokensDisabled is returned for bearer tokens when no secret is configured
+// to verify them
+var ErrTokensDisabled = errors.New("bearer tokens are not accepted, AUTH_TOKEN_SECRET is not set")
+
+// Authenticator identifies the caller of an RPC from verified credentials
+type Authenticator struct {
+	tokens   *TokenVerifier
+	loopback func(cert *x509.Certificate) bool
+}
+
+// NewAuthenticator returns an authenticator that verifies bearer tokens with
+// tokens, or rejects them when tokens is nil. Client certificates for which
+// loopback returns true are this process's own, presented by the HTTP gateway,
+// and identify no caller. loopback may be nil.
+func NewAuthenticator(tokens *TokenVerifier, loopback func(cert *x509.Certificate) bool) *Authenticator {
+	return &Authenticator{tokens: tokens, loopback: loopback}
+}
+
+// Authenticate returns the caller of the RPC in ctx, verified from the bearer
+// token in the authorization metadata or else from the client certificate
+// verified by mTLS, which identifies a service. Calls without credentials are
+// anonymous.
+func (a *Authenticator) Authenticate(ctx context.Context) (Principal, error) {
+	md, _ := metadata.FromIncomingContext(ctx)
+
+	if header := first(md.Get(AuthorizationHeader)); header != "" {
+		token, found := strings.CutPrefix(header, "Bearer ")
+		if !found {
+			return Principal{}, ErrInvalidToken
+		}
+		if a.tokens == nil {
+			return Principal{}, ErrTokensDisabled
+		}
+		return a.tokens.Verify(token)
+	}
+
+	if cert := peerCertificate(ctx); cert != nil && (a.loopback == nil || !a.loopback(cert)) {
+		if id := certificateID(cert); id != "" {
+			return Principal{ID: id, Role: RoleService}, nil
+		}
+	}
+
+	if len(md.Get(UserIDHeader)) > 0 || len(md.Get(UserRoleHeader)) > 0 {
+		return Principal{}, ErrUnverifiedIdentity
+	}
+	return Principal{}, nil
+}
+
+// peerCertificate returns the client certificate verified by mTLS, if any
+func peerCertificate(ctx context.Context) *x509.Certificate {
+	p, ok := peer.FromContext(ctx)
+	if !ok {
+		return nil
+	}
+	info, ok := p.AuthInfo.(credentials.TLSInfo)
+	if !ok || len(info.State.VerifiedChains) == 0 || len(info.State.VerifiedChains[0]) == 0 {
+		return nil
+	}
+	return info.State.VerifiedChains[0][0]
+}
+
+// certificateID names the service a client certificate was issued to: its
+// common name, or else its first URI or DNS name
+func certificateID(cert *x509.Certificate) string {
+	switch {
+	case cert.Subject.CommonName != "":
+		return cert.Subject.CommonName
+	case len(cert.URIs) > 0:
+		return cert.URIs[0].String()
+	case len(cert.DNSNames) > 0:
+		return cert.DNSNames[0]
+	}
+	return ""
+}
+
+type principalKey struct{}
+
+func NewContext(ctx context.Context, principal Principal) context.Context {
+	return context.WithValue(ctx, principalKey{}, principal)
+}
+
+// FromContext returns the principal stored in ctx, or the anonymous principal
+func FromContext(ctx context.Context) Principal {
+	principal, _ := ctx.Value(principalKey{}).(Principal)
+	return principal
+}
+
+func first(values []string) string {
+	if len(values) == 0 {
+		return ""
+	}
+	return values[0]
+}
@@ -0,0 +1,26 @@
+package dispatcher
+
+import (
+	"context"
+	"fmt"
+
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+)
+
+// Dispatcher publishes outgoing reminder messages to the notification pipeline
+type Dispatcher interface {
+	Send(ctx context.Context, message *models.OutboxMessage) error
+}
+
+// NewDispatcher creates the dispatcher selected by DISPATCHER
+func NewDispatcher(ctx context.Context, cfg *config.Config) (Dispatcher, error) {
+	switch cfg.DISPATCHER {
+	case "", "log":
+		return NewLogDispatcher(), nil
+	case "sqs":
+		return NewSQSDispatcher(ctx, cfg)
+	default:
+		return nil, fmt.Errorf("unknown dispatcher %q", cfg.DISPATCHER)
+	}
+}
@@ -0,0 +1,42 @@
+package dispatcher
+
+import (
+	"context"
+	"encoding/json"
+
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/pkg/redact"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+)
+
+type logDispatcher struct{}
+
+// NewLogDispatcher creates a dispatcher that only logs messages, for local development
+func NewLogDispatcher() Dispatcher {
+	return &logDispatcher{}
+}
+
+func (d *logDispatcher) Send(ctx context.Context, message *models.OutboxMessage) error {
+	utils.InfoContext(ctx, "Reminder queue", map[string]interface{}{
+		"dedupe_key": message.DedupeKey,
+		"message":    redactPayload(message.Payload),
+	})
+	return nil
+}
+
+// redactPayload masks the contact details in a message payload before it is logged
+func redactPayload(payload string) interface{} {
+	var fields map[string]interface{}
+	if err := json.Unmarshal([]byte(payload), &fields); err != nil {
+		// Never log a payload that could not be redacted
+		return "[unparseable payload]"
+	}
+
+	if email, ok := fields["email"].(string); ok {
+		fields["email"] = redact.Email(email)
+	}
+	if phone, ok := fields["phone"].(string); ok {
+		fields["phone"] = redact.Phone(phone)
+	}
+	return fields
+}
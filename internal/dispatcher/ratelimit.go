@@ -0,0 +1,195 @@
+package dispatcher
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/metrics"
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+	"golang.org/x/time/rate"
+)
+
+const (
+	RateLimitScopeGlobal   = "global"
+	RateLimitScopeChannel  = "channel"
+	RateLimitScopeCustomer = "customer"
+)
+
+// RateLimitError is returned when a message is over a rate limit. The message
+// should be deferred, not dropped.
+type RateLimitError struct {
+	Scope      string
+	RetryAfter time.Duration
+}
+
+func (e *RateLimitError) Error() string {
+	return fmt.Sprintf("%s rate limit exceeded, retry after %s", e.Scope, e.RetryAfter)
+}
+
+// IsRateLimitError checks if an error is a RateLimitError
+func IsRateLimitError(err error) (*RateLimitError, bool) {
+	var limitErr *RateLimitError
+	ok := errors.As(err, &limitErr)
+	return limitErr, ok
+}
+
+// RateLimit allows Count messages per Period, in bursts of up to Count
+type RateLimit struct {
+	Count  int
+	Period time.Duration
+}
+
+// ParseRateLimit parses limits such as "3/24h". An empty string disables the limit.
+func ParseRateLimit(value string) (*RateLimit, error) {
+	if value == "" {
+		return nil, nil
+	}
+
+	count, period, found := strings.Cut(value, "/")
+	if !found {
+		return nil, fmt.Errorf("invalid rate limit %q, expected <count>/<duration>", value)
+	}
+
+	n, err := strconv.Atoi(count)
+	if err != nil || n <= 0 {
+		return nil, fmt.Errorf("invalid rate limit count in %q", value)
+	}
+
+	d, err := time.ParseDuration(period)
+	if err != nil || d <= 0 {
+		return nil, fmt.Errorf("invalid rate limit period in %q", value)
+	}
+
+	return &RateLimit{Count: n, Period: d}, nil
+}
+
+func (l *RateLimit) newLimiter() *rate.Limiter {
+	return rate.NewLimiter(rate.Limit(float64(l.Count)/l.Period.Seconds()), l.Count)
+}
+
+type rateLimitedDispatcher struct {
+	next Dispatcher
+
+	global   *rate.Limiter
+	channel  *RateLimit
+	customer *RateLimit
+
+	mu        sync.Mutex
+	channels  map[string]*rate.Limiter
+	customers map[string]*rate.Limiter
+}
+
+// NewRateLimitedDispatcher wraps a dispatcher with token-bucket limits that apply
+// globally, per channel and per customer. A message must fit every bucket it
+// touches; otherwise no tokens are taken and a RateLimitError is returned.
+func NewRateLimitedDispatcher(next Dispatcher, cfg *config.Config) (Dispatcher, error) {
+	global, err := ParseRateLimit(cfg.RATE_LIMIT_GLOBAL)
+	if err != nil {
+		return nil, err
+	}
+
+	channel, err := ParseRateLimit(cfg.RATE_LIMIT_CHANNEL)
+	if err != nil {
+		return nil, err
+	}
+
+	customer, err := ParseRateLimit(cfg.RATE_LIMIT_CUSTOMER)
+	if err != nil {
+		return nil, err
+	}
+
+	if global == nil && channel == nil && customer == nil {
+		return next, nil
+	}
+
+	d := &rateLimitedDispatcher{
+		next:      next,
+		channel:   channel,
+		customer:  customer,
+		channels:  make(map[string]*rate.Limiter),
+		customers: make(map[string]*rate.Limiter),
+	}
+	if global != nil {
+		d.global = global.newLimiter()
+	}
+	return d, nil
+}
+
+func (d *rateLimitedDispatcher) Send(ctx context.Context, message *models.OutboxMessage) error {
+	if err := d.reserve(message); err != nil {
+		return err
+	}
+	return d.next.Send(ctx, message)
+}
+
+func (d *rateLimitedDispatcher) reserve(message *models.OutboxMessage) error {
+	d.mu.Lock()
+	defer d.mu.Unlock()
+
+	now := time.Now()
+
+	type bucket struct {
+		scope   string
+		limiter *rate.Limiter
+	}
+
+	var buckets []bucket
+	if d.global != nil {
+		buckets = append(buckets, bucket{RateLimitScopeGlobal, d.global})
+	}
+	if d.channel != nil {
+		for _, channel := range strings.Split(message.Channels, ",") {
+			if channel != "" {
+				buckets = append(buckets, bucket{RateLimitScopeChannel, d.limiter(d.channels, channel, d.channel)})
+			}
+		}
+	}
+	if d.customer != nil {
+		buckets = append(buckets, bucket{RateLimitScopeCustomer, d.limiter(d.customers, message.CustomerID.String(), d.customer)})
+	}
+
+	reservations := make([]*rate.Reservation, 0, len(buckets))
+	for _, b := range buckets {
+		reservation := b.limiter.ReserveN(now, 1)
+		if delay := reservation.DelayFrom(now); delay > 0 {
+			reservation.CancelAt(now)
+			for _, r := range reservations {
+				r.CancelAt(now)
+			}
+			metrics.ThrottledMessages.WithLabelValues(b.scope).Inc()
+			return &RateLimitError{Scope: b.scope, RetryAfter: delay}
+		}
+		reservations = append(reservations, reservation)
+	}
+
+	d.prune(now)
+	return nil
+}
+
+func (d *rateLimitedDispatcher) limiter(limiters map[string]*rate.Limiter, key string, limit *RateLimit) *rate.Limiter {
+	limiter, ok := limiters[key]
+	if !ok {
+		limiter = limit.newLimiter()
+		limiters[key] = limiter
+	}
+	return limiter
+}
+
+// prune drops per-customer buckets that have refilled, since a new bucket
+// behaves the same. This keeps memory bounded by recently active customers.
+func (d *rateLimitedDispatcher) prune(now time.Time) {
+	if len(d.customers) < 10000 {
+		return
+	}
+	for key, limiter := range d.customers {
+		if limiter.TokensAt(now) >= float64(limiter.Burst()) {
+			delete(d.customers, key)
+		}
+	}
+}
@@ -0,0 +1,63 @@
+package dispatcher
+
+import (
+	"context"
+	"strings"
+
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/internal/tracing"
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+type sqsDispatcher struct {
+	client   *sqs.Client
+	queueURL string
+}
+
+// NewSQSDispatcher creates a dispatcher that sends messages to the reminder queue
+func NewSQSDispatcher(ctx context.Context, cfg *config.Config) (Dispatcher, error) {
+	awsCfg, err := utils.LoadAWSConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqsDispatcher{
+		client:   sqs.NewFromConfig(awsCfg),
+		queueURL: cfg.SQS_QUEUE_URL,
+	}, nil
+}
+
+func (d *sqsDispatcher) Send(ctx context.Context, message *models.OutboxMessage) error {
+	input := &sqs.SendMessageInput{
+		QueueUrl:    aws.String(d.queueURL),
+		MessageBody: aws.String(message.Payload),
+		// Consumers use the dedupe key to drop redelivered messages
+		MessageAttributes: map[string]types.MessageAttributeValue{
+			"dedupe_key": {
+				DataType:    aws.String("String"),
+				StringValue: aws.String(message.DedupeKey),
+			},
+		},
+	}
+
+	// Let consumers continue the relay's trace
+	if traceParent := tracing.Inject(ctx); traceParent != "" {
+		input.MessageAttributes["traceparent"] = types.MessageAttributeValue{
+			DataType:    aws.String("String"),
+			StringValue: aws.String(traceParent),
+		}
+	}
+
+	// FIFO queues deduplicate on their own
+	if strings.HasSuffix(d.queueURL, ".fifo") {
+		input.MessageDeduplicationId = aws.String(message.DedupeKey)
+		input.MessageGroupId = aws.String(message.CustomerID.String())
+	}
+
+	_, err := d.client.SendMessage(ctx, input)
+	return err
+}
@@ -0,0 +1,174 @@
+package encryption
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"encoding/base64"
+	"fmt"
+	"strings"
+
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+)
+
+// prefix marks an encrypted value, so values stored before encryption was
+// enabled are still read as plaintext
+const prefix = "enc:v1:"
+
+const dataKeySize = 32
+
+// KeyProvider wraps and unwraps data keys with a key encryption key that never
+// leaves the provider, like a KMS does
+type KeyProvider interface {
+	// WrapKey encrypts a data key with the active key and returns that key's ID
+	WrapKey(ctx context.Context, dataKey []byte) (keyID string, wrapped []byte, err error)
+	// UnwrapKey decrypts a data key wrapped by the key with the given ID
+	UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error)
+}
+
+// Cipher envelope-encrypts values with a fresh data key per value. A Cipher
+// without a key provider leaves values in plaintext.
+type Cipher struct {
+	provider KeyProvider
+}
+
+// NewCipher creates a cipher for the configured ENCRYPTION_KEYS. Encryption is
+// disabled when no keys are configured.
+func NewCipher(cfg *config.Config) (*Cipher, error) {
+	if cfg.ENCRYPTION_KEYS == "" {
+		return &Cipher{}, nil
+	}
+
+	keys, err := ParseKeys(cfg.ENCRYPTION_KEYS)
+	if err != nil {
+		return nil, err
+	}
+
+	provider, err := NewLocalKeyProvider(keys, cfg.ENCRYPTION_KEY_ID)
+	if err != nil {
+		return nil, err
+	}
+	return NewCipherWithProvider(provider), nil
+}
+
+// NewCipherWithProvider creates a cipher that wraps its data keys with provider
+func NewCipherWithProvider(provider KeyProvider) *Cipher {
+	return &Cipher{provider: provider}
+}
+
+// Enabled reports whether values are encrypted
+func (c *Cipher) Enabled() bool {
+	return c.provider != nil
+}
+
+// Encrypt returns plaintext encrypted under a new data key, together with the
+// wrapped data key and the ID of the key that wrapped it
+func (c *Cipher) Encrypt(ctx context.Context, plaintext string) (string, error) {
+	if !c.Enabled() || plaintext == "" {
+		return plaintext, nil
+	}
+
+	dataKey := make([]byte, dataKeySize)
+	if _, err := rand.Read(dataKey); err != nil {
+		return "", fmt.Errorf("failed to generate data key: %w", err)
+	}
+
+	keyID, wrapped, err := c.provider.WrapKey(ctx, dataKey)
+	if err != nil {
+		return "", fmt.Errorf("failed to wrap data key: %w", err)
+	}
+
+	sealed, err := seal(dataKey, []byte(plaintext))
+	if err != nil {
+		return "", err
+	}
+
+	return prefix + strings.Join([]string{
+		keyID,
+		base64.RawStdEncoding.EncodeToString(wrapped),
+		base64.RawStdEncoding.EncodeToString(sealed),
+	}, ":"), nil
+}
+
+// Decrypt reverses Encrypt. Values that were never encrypted are returned as is.
+func (c *Cipher) Decrypt(ctx context.Context, value string) (string, error) {
+	if !IsEncrypted(value) {
+		return value, nil
+	}
+	if !c.Enabled() {
+		return "", fmt.Errorf("value is encrypted but no encryption keys are configured")
+	}
+
+	parts := strings.Split(strings.TrimPrefix(value, prefix), ":")
+	if len(parts) != 3 {
+		return "", fmt.Errorf("malformed encrypted value")
+	}
+
+	wrapped, err := base64.RawStdEncoding.DecodeString(parts[1])
+	if err != nil {
+		return "", fmt.Errorf("malformed wrapped data key: %w", err)
+	}
+
+	sealed, err := base64.RawStdEncoding.DecodeString(parts[2])
+	if err != nil {
+		return "", fmt.Errorf("malformed ciphertext: %w", err)
+	}
+
+	dataKey, err := c.provider.UnwrapKey(ctx, parts[0], wrapped)
+	if err != nil {
+		return "", fmt.Errorf("failed to unwrap data key: %w", err)
+	}
+
+	plaintext, err := open(dataKey, sealed)
+	if err != nil {
+		return "", err
+	}
+	return string(plaintext), nil
+}
+
+// IsEncrypted reports whether value was produced by Encrypt
+func IsEncrypted(value string) bool {
+	return strings.HasPrefix(value, prefix)
+}
+
+// seal encrypts plaintext with AES-GCM and prepends the nonce
+func seal(key, plaintext []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, fmt.Errorf("failed to generate nonce: %w", err)
+	}
+	return aead.Seal(nonce, nonce, plaintext, nil), nil
+}
+
+// open decrypts a value produced by seal
+func open(key, sealed []byte) ([]byte, error) {
+	aead, err := newAEAD(key)
+	if err != nil {
+		return nil, err
+	}
+
+	if len(sealed) < aead.NonceSize() {
+		return nil, fmt.Errorf("ciphertext too short")
+	}
+
+	nonce, ciphertext := sealed[:aead.NonceSize()], sealed[aead.NonceSize():]
+	plaintext, err := aead.Open(nil, nonce, ciphertext, nil)
+	if err != nil {
+		return nil, fmt.Errorf("failed to decrypt: %w", err)
+	}
+	return plaintext, nil
+}
+
+func newAEAD(key []byte) (cipher.AEAD, error) {
+	block, err := aes.NewCipher(key)
+	if err != nil {
+		return nil, err
+	}
+	return cipher.NewGCM(block)
+}
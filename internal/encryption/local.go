@@ -0,0 +1,92 @@
+package encryption
+
+import (
+	"context"
+	"encoding/base64"
+	"fmt"
+	"strings"
+)
+
+// LocalKeyProvider keeps key encryption keys in memory. It stands in for a KMS
+// in development and in deployments that manage keys through config.
+type LocalKeyProvider struct {
+	keys     map[string][]byte
+	activeID string
+}
+
+// NewLocalKeyProvider creates a provider that wraps data keys with the key
+// activeID and unwraps them with any of keys, so old keys can be kept around
+// for decryption after a rotation. activeID defaults to the only key.
+func NewLocalKeyProvider(keys map[string][]byte, activeID string) (*LocalKeyProvider, error) {
+	if len(keys) == 0 {
+		return nil, fmt.Errorf("no encryption keys configured")
+	}
+
+	for id, key := range keys {
+		if len(key) != dataKeySize {
+			return nil, fmt.Errorf("encryption key %q must be %d bytes, got %d", id, dataKeySize, len(key))
+		}
+	}
+
+	if activeID == "" {
+		if len(keys) > 1 {
+			return nil, fmt.Errorf("ENCRYPTION_KEY_ID is required when more than one key is configured")
+		}
+		for id := range keys {
+			activeID = id
+		}
+	}
+
+	if _, ok := keys[activeID]; !ok {
+		return nil, fmt.Errorf("active encryption key %q is not configured", activeID)
+	}
+
+	return &LocalKeyProvider{
+		keys:     keys,
+		activeID: activeID,
+	}, nil
+}
+
+func (p *LocalKeyProvider) WrapKey(ctx context.Context, dataKey []byte) (string, []byte, error) {
+	wrapped, err := seal(p.keys[p.activeID], dataKey)
+	if err != nil {
+		return "", nil, err
+	}
+	return p.activeID, wrapped, nil
+}
+
+func (p *LocalKeyProvider) UnwrapKey(ctx context.Context, keyID string, wrapped []byte) ([]byte, error) {
+	key, ok := p.keys[keyID]
+	if !ok {
+		return nil, fmt.Errorf("unknown encryption key %q", keyID)
+	}
+	return open(key, wrapped)
+}
+
+// ParseKeys parses comma-separated "id:key" pairs, where key is a base64
+// encoded 32-byte key, e.g. "2024-01:c2VjcmV0...,2024-06:bmV3ZXI..."
+func ParseKeys(spec string) (map[string][]byte, error) {
+	keys := make(map[string][]byte)
+	for _, pair := range strings.Split(spec, ",") {
+		pair = strings.TrimSpace(pair)
+		if pair == "" {
+			continue
+		}
+
+		id, encoded, found := strings.Cut(pair, ":")
+		if !found || id == "" {
+			return nil, fmt.Errorf("invalid encryption key %q, expected id:base64-key", pair)
+		}
+
+		key, err := base64.StdEncoding.DecodeString(encoded)
+		if err != nil {
+			return nil, fmt.Errorf("invalid encryption key %q: %w", id, err)
+		}
+
+		if _, exists := keys[id]; exists {
+			return nil, fmt.Errorf("duplicate encryption key %q", id)
+		}
+		keys[id] = key
+	}
+	return keys, nil
+}
@@ -0,0 +1,78 @@
+package eventbus
+
+import (
+	"context"
+	"fmt"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+	"gorm.io/gorm"
+)
+
+// EventType identifies a change to a reminder pushed to watchers
+type EventType string
+
+const (
+	ReminderCreated   EventType = "reminder.created"
+	ReminderUpdated   EventType = "reminder.updated"
+	ReminderDeleted   EventType = "reminder.deleted"
+	ReminderSent      EventType = "reminder.sent"
+	ReminderFailed    EventType = "reminder.failed"
+	ReminderLogAppend EventType = "reminder_log.appended"
+)
+
+// Event is a change to a reminder. Events about a digest carry the digest ID
+// instead of a reminder ID.
+type Event struct {
+	Type       EventType `json:"type"`
+	ReminderID string    `json:"reminder_id,omitempty"`
+	CustomerID string    `json:"customer_id,omitempty"`
+	DigestID   string    `json:"digest_id,omitempty"`
+	MessageID  string    `json:"message_id,omitempty"`
+	// Log status for appended log entries, the error for failures
+	Status     string    `json:"status,omitempty"`
+	OccurredAt time.Time `json:"occurred_at"`
+}
+
+// Filter selects the events a subscriber receives. Empty fields match every event.
+type Filter struct {
+	CustomerID string
+	ReminderID string
+}
+
+// Matches reports whether event passes the filter
+func (f Filter) Matches(event Event) bool {
+	if f.CustomerID != "" && f.CustomerID != event.CustomerID {
+		return false
+	}
+	if f.ReminderID != "" && f.ReminderID != event.ReminderID {
+		return false
+	}
+	return true
+}
+
+// Bus fans reminder events out to subscribers. Publishing never blocks on
+// subscribers: one that falls behind is dropped and its channel closed. Every
+// channel is closed once Start returns.
+type Bus interface {
+	Publish(ctx context.Context, event Event)
+	// Subscribe returns a channel of matching events and a function that
+	// cancels the subscription
+	Subscribe(filter Filter) (<-chan Event, func())
+	// Start runs until ctx is cancelled; it delivers events published by other
+	// replicas when the bus is shared
+	Start(ctx context.Context) error
+}
+
+// NewBus creates the bus selected by REMINDER_EVENTS_BUS: "memory" only reaches
+// watchers of this replica, "postgres" uses LISTEN/NOTIFY to reach every replica.
+func NewBus(cfg *config.Config, db *gorm.DB) (Bus, error) {
+	switch cfg.REMINDER_EVENTS_BUS {
+	case "", "memory":
+		return NewMemoryBus(), nil
+	case "postgres":
+		return NewPostgresBus(db, cfg.DBConnString), nil
+	default:
+		return nil, fmt.Errorf("unknown reminder events bus %q", cfg.REMINDER_EVENTS_BUS)
+	}
+}
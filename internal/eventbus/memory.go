@@ -0,0 +1,100 @@
+package eventbus
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// subscriberBuffer is how many events a subscriber can fall behind before it is dropped
+const subscriberBuffer = 256
+
+type subscriber struct {
+	filter Filter
+	events chan Event
+}
+
+type memoryBus struct {
+	mu          sync.Mutex
+	subscribers map[*subscriber]struct{}
+	closed      bool
+}
+
+// NewMemoryBus creates a bus that only delivers events within this process
+func NewMemoryBus() Bus {
+	return &memoryBus{
+		subscribers: make(map[*subscriber]struct{}),
+	}
+}
+
+func (b *memoryBus) Publish(ctx context.Context, event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+	b.deliver(event)
+}
+
+func (b *memoryBus) deliver(event Event) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	for sub := range b.subscribers {
+		if !sub.filter.Matches(event) {
+			continue
+		}
+
+		select {
+		case sub.events <- event:
+		default:
+			// Drop the subscriber rather than block the publisher
+			delete(b.subscribers, sub)
+			close(sub.events)
+		}
+	}
+}
+
+func (b *memoryBus) Subscribe(filter Filter) (<-chan Event, func()) {
+	sub := &subscriber{
+		filter: filter,
+		events: make(chan Event, subscriberBuffer),
+	}
+
+	b.mu.Lock()
+	if b.closed {
+		close(sub.events)
+	} else {
+		b.subscribers[sub] = struct{}{}
+	}
+	b.mu.Unlock()
+
+	var once sync.Once
+	cancel := func() {
+		once.Do(func() {
+			b.mu.Lock()
+			defer b.mu.Unlock()
+			if _, ok := b.subscribers[sub]; ok {
+				delete(b.subscribers, sub)
+				close(sub.events)
+			}
+		})
+	}
+	return sub.events, cancel
+}
+
+func (b *memoryBus) Start(ctx context.Context) error {
+	<-ctx.Done()
+	b.close()
+	return nil
+}
+
+// close ends every subscription, so streams do not hold up a shutdown
+func (b *memoryBus) close() {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	b.closed = true
+	for sub := range b.subscribers {
+		delete(b.subscribers, sub)
+		close(sub.events)
+	}
+}
@@ -0,0 +1,184 @@
+package eventbus
+
+import (
+	"context"
+	"encoding/json"
+	"strings"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"github.com/jackc/pgx/v5"
+	"gorm.io/gorm"
+)
+
+// notifyChannel is the Postgres channel reminder events are sent on
+const notifyChannel = "reminder_events"
+
+const (
+	minReconnectDelay = time.Second
+	maxReconnectDelay = time.Minute
+)
+
+const (
+	// publishBuffer is how many events can wait to be sent before new ones
+	// are dropped
+	publishBuffer = 4096
+	// publishBatchSize is the most events sent in one statement
+	publishBatchSize = 500
+)
+
+// postgresBus publishes events with NOTIFY and delivers every notification,
+// including its own, to local subscribers. Events are queued and sent in
+// batches, so publishers never wait on the database.
+type postgresBus struct {
+	local      *memoryBus
+	db         *gorm.DB
+	connString string
+	pending    chan Event
+}
+
+// NewPostgresBus creates a bus shared by every replica connected to the same
+// database. Start must be running for subscribers to receive events.
+func NewPostgresBus(db *gorm.DB, connString string) Bus {
+	return &postgresBus{
+		local:      NewMemoryBus().(*memoryBus),
+		db:         db,
+		connString: connString,
+		pending:    make(chan Event, publishBuffer),
+	}
+}
+
+func (b *postgresBus) Publish(ctx context.Context, event Event) {
+	if event.OccurredAt.IsZero() {
+		event.OccurredAt = time.Now()
+	}
+
+	// Events are best effort; a full queue never holds up the change
+	select {
+	case b.pending <- event:
+	default:
+		utils.WarnContext(ctx, "Dropping reminder event, the publish queue is full", map[string]interface{}{
+			"type": event.Type,
+		})
+	}
+}
+
+// publish sends queued events until ctx is cancelled. Events that queued up
+// while a statement ran go out together in the next one.
+func (b *postgresBus) publish(ctx context.Context) {
+	for {
+		var batch []Event
+		select {
+		case <-ctx.Done():
+			return
+		case event := <-b.pending:
+			batch = append(batch, event)
+		}
+
+	collect:
+		for len(batch) < publishBatchSize {
+			select {
+			case event := <-b.pending:
+				batch = append(batch, event)
+			default:
+				break collect
+			}
+		}
+
+		b.notify(ctx, batch)
+	}
+}
+
+// notify sends events with one pg_notify per event in a single statement
+func (b *postgresBus) notify(ctx context.Context, events []Event) {
+	rows := make([]string, 0, len(events))
+	args := []interface{}{notifyChannel}
+	for _, event := range events {
+		payload, err := json.Marshal(event)
+		if err != nil {
+			utils.Error("Failed to marshal reminder event", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+		rows = append(rows, "(?)")
+		args = append(args, string(payload))
+	}
+	if len(rows) == 0 {
+		return
+	}
+
+	query := "SELECT pg_notify(?, payload) FROM (VALUES " + strings.Join(rows, ", ") + ") AS events(payload)"
+	if err := b.db.WithContext(ctx).Exec(query, args...).Error; err != nil {
+		utils.Warn("Failed to publish reminder events", map[string]interface{}{
+			"error":  err,
+			"events": len(rows),
+		})
+	}
+}
+
+func (b *postgresBus) Subscribe(filter Filter) (<-chan Event, func()) {
+	return b.local.Subscribe(filter)
+}
+
+// Start sends published events and listens for notifications until ctx is
+// cancelled, reconnecting with backoff when the connection drops. Events sent
+// while disconnected are lost.
+func (b *postgresBus) Start(ctx context.Context) error {
+	defer b.local.close()
+	go b.publish(ctx)
+
+	delay := minReconnectDelay
+	for {
+		started := time.Now()
+		err := b.listen(ctx)
+		if ctx.Err() != nil {
+			return nil
+		}
+
+		// A connection that held up for a while starts the backoff over
+		if time.Since(started) > maxReconnectDelay {
+			delay = minReconnectDelay
+		}
+
+		utils.Warn("Reminder event listener disconnected", map[string]interface{}{
+			"error":           err,
+			"reconnect_after": delay.String(),
+		})
+
+		select {
+		case <-ctx.Done():
+			return nil
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, maxReconnectDelay)
+	}
+}
+
+func (b *postgresBus) listen(ctx context.Context) error {
+	conn, err := pgx.Connect(ctx, b.connString)
+	if err != nil {
+		return err
+	}
+	defer conn.Close(context.Background())
+
+	if _, err := conn.Exec(ctx, "LISTEN "+notifyChannel); err != nil {
+		return err
+	}
+
+	for {
+		notification, err := conn.WaitForNotification(ctx)
+		if err != nil {
+			return err
+		}
+
+		var event Event
+		if err := json.Unmarshal([]byte(notification.Payload), &event); err != nil {
+			utils.Warn("Ignoring malformed reminder event", map[string]interface{}{
+				"error": err,
+			})
+			continue
+		}
+		b.local.deliver(event)
+	}
+}
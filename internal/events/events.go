@@ -0,0 +1,103 @@
+package events
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+	apperrors "github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/google/uuid"
+)
+
+// EventType identifies an event published by the order service
+type EventType string
+
+const (
+	OrderCreated   EventType = "order.created"
+	OrderCancelled EventType = "order.cancelled"
+	OrderRefunded  EventType = "order.refunded"
+)
+
+// OrderItem is a single product line of an order
+type OrderItem struct {
+	ProductID  string `json:"product_id"`
+	SupplyDays int32  `json:"supply_days"`
+}
+
+// OrderEvent is the payload published by the order service
+type OrderEvent struct {
+	ID         string      `json:"id"`
+	Type       EventType   `json:"type"`
+	CustomerID string      `json:"customer_id"`
+	OrderID    string      `json:"order_id"`
+	Items      []OrderItem `json:"items"`
+	OccurredAt time.Time   `json:"occurred_at"`
+}
+
+// ErrInvalidEvent is returned for events that can never be handled, such as
+// ones with malformed IDs
+var ErrInvalidEvent = errors.New("invalid order event")
+
+// validate checks the IDs the event is handled with
+func (e OrderEvent) validate() error {
+	if _, err := uuid.Parse(e.OrderID); err != nil {
+		return fmt.Errorf("%w: order_id %q is not a UUID", ErrInvalidEvent, e.OrderID)
+	}
+	if e.Type != OrderCreated {
+		return nil
+	}
+
+	if _, err := uuid.Parse(e.CustomerID); err != nil {
+		return fmt.Errorf("%w: customer_id %q is not a UUID", ErrInvalidEvent, e.CustomerID)
+	}
+	for _, item := range e.Items {
+		if _, err := uuid.Parse(item.ProductID); err != nil {
+			return fmt.Errorf("%w: product_id %q is not a UUID", ErrInvalidEvent, item.ProductID)
+		}
+	}
+	return nil
+}
+
+// Handler processes a single order event. Returning an error leaves the event
+// to be redelivered by the consumer, unless the error is not Retryable.
+type Handler func(ctx context.Context, event OrderEvent) error
+
+// Retryable reports whether an event that failed with err may succeed when it
+// is delivered again. Invalid events, and events the services reject as bad
+// requests, fail the same way every time; consumers drop them.
+func Retryable(err error) bool {
+	if errors.Is(err, ErrInvalidEvent) {
+		return false
+	}
+	if appErr, ok := apperrors.IsAppError(err); ok {
+		switch appErr.Type {
+		case apperrors.ValidationError, apperrors.BadRequestError:
+			return false
+		}
+	}
+	return true
+}
+
+// Consumer delivers inbound order events to a handler until the context is cancelled
+type Consumer interface {
+	Start(ctx context.Context, handler Handler) error
+}
+
+// NewConsumer creates the consumer selected by EVENT_SOURCE. It returns nil when
+// event consumption is disabled.
+func NewConsumer(ctx context.Context, cfg *config.Config) (Consumer, error) {
+	switch cfg.EVENT_SOURCE {
+	case "":
+		return nil, nil
+	case "sqs":
+		return NewSQSConsumer(ctx, cfg)
+	case "webhook":
+		return NewWebhookConsumer(":"+cfg.EVENTS_WEBHOOK_PORT, cfg.EVENTS_WEBHOOK_SECRET), nil
+	case "memory":
+		return NewMemoryConsumer(), nil
+	default:
+		return nil, fmt.Errorf("unknown event source %q", cfg.EVENT_SOURCE)
+	}
+}
@@ -0,0 +1,50 @@
+package events
+
+import (
+	"context"
+)
+
+type delivery struct {
+	event  OrderEvent
+	result chan error
+}
+
+// MemoryConsumer is an in-process consumer used in tests and local development
+type MemoryConsumer struct {
+	deliveries chan delivery
+}
+
+func NewMemoryConsumer() *MemoryConsumer {
+	return &MemoryConsumer{
+		deliveries: make(chan delivery),
+	}
+}
+
+func (c *MemoryConsumer) Start(ctx context.Context, handler Handler) error {
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case d := <-c.deliveries:
+			d.result <- handler(ctx, d.event)
+		}
+	}
+}
+
+// Publish hands an event to the running consumer and returns the handler's result
+func (c *MemoryConsumer) Publish(ctx context.Context, event OrderEvent) error {
+	d := delivery{event: event, result: make(chan error, 1)}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case c.deliveries <- d:
+	}
+
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case err := <-d.result:
+		return err
+	}
+}
@@ -0,0 +1,55 @@
+package events
+
+import (
+	"context"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/services"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+)
+
+// NewOrderEventHandler schedules, advances or cancels reminders from order events.
+// Every branch is safe to replay, so redelivered events are harmless.
+func NewOrderEventHandler(reminderService services.ReminderService) Handler {
+	return func(ctx context.Context, event OrderEvent) error {
+		ctx = utils.ContextWithLogFields(ctx, map[string]interface{}{
+			"event_id":   event.ID,
+			"event_type": event.Type,
+		})
+
+		switch event.Type {
+		case OrderCreated:
+			if err := event.validate(); err != nil {
+				return err
+			}
+
+			orderedAt := event.OccurredAt
+			if orderedAt.IsZero() {
+				orderedAt = time.Now()
+			}
+
+			for _, item := range event.Items {
+				// Only prescription items carry a supply length
+				if item.SupplyDays <= 0 {
+					continue
+				}
+
+				err := reminderService.OrderCreated(ctx, event.CustomerID, event.OrderID, item.ProductID, item.SupplyDays, orderedAt)
+				if err != nil {
+					return err
+				}
+			}
+		case OrderCancelled, OrderRefunded:
+			if err := event.validate(); err != nil {
+				return err
+			}
+			return reminderService.CancelOrderReminders(ctx, event.OrderID)
+		default:
+			utils.WarnContext(ctx, "Ignoring unknown order event", map[string]interface{}{
+				"event_id": event.ID,
+				"type":     event.Type,
+			})
+		}
+		return nil
+	}
+}
@@ -0,0 +1,98 @@
+package events
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	"github.com/aws/aws-sdk-go-v2/service/sqs"
+	"github.com/aws/aws-sdk-go-v2/service/sqs/types"
+)
+
+type sqsConsumer struct {
+	client   *sqs.Client
+	queueURL string
+}
+
+// NewSQSConsumer creates a consumer that long-polls the order events queue
+func NewSQSConsumer(ctx context.Context, cfg *config.Config) (Consumer, error) {
+	awsCfg, err := utils.LoadAWSConfig(ctx, cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	return &sqsConsumer{
+		client:   sqs.NewFromConfig(awsCfg),
+		queueURL: cfg.ORDER_EVENTS_QUEUE_URL,
+	}, nil
+}
+
+func (c *sqsConsumer) Start(ctx context.Context, handler Handler) error {
+	for {
+		output, err := c.client.ReceiveMessage(ctx, &sqs.ReceiveMessageInput{
+			QueueUrl:            aws.String(c.queueURL),
+			MaxNumberOfMessages: 10,
+			WaitTimeSeconds:     20,
+		})
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			utils.Error("Failed to receive order events", map[string]interface{}{
+				"error": err,
+			})
+			select {
+			case <-ctx.Done():
+				return nil
+			case <-time.After(5 * time.Second):
+			}
+			continue
+		}
+
+		for _, message := range output.Messages {
+			var event OrderEvent
+			if err := json.Unmarshal([]byte(aws.ToString(message.Body)), &event); err != nil {
+				// It will never parse, so it is dropped rather than redelivered
+				utils.Error("Dropping order event that cannot be parsed", map[string]interface{}{
+					"error":      err,
+					"message_id": aws.ToString(message.MessageId),
+				})
+				c.delete(ctx, message)
+				continue
+			}
+
+			if err := handler(ctx, event); err != nil {
+				fields := map[string]interface{}{
+					"error":    err,
+					"event_id": event.ID,
+					"type":     event.Type,
+				}
+				if Retryable(err) {
+					// Left on the queue to be redelivered after the visibility timeout
+					utils.Error("Failed to handle order event", fields)
+					continue
+				}
+				utils.Error("Dropping invalid order event", fields)
+			}
+
+			c.delete(ctx, message)
+		}
+	}
+}
+
+// delete removes a handled or dropped message from the queue
+func (c *sqsConsumer) delete(ctx context.Context, message types.Message) {
+	_, err := c.client.DeleteMessage(ctx, &sqs.DeleteMessageInput{
+		QueueUrl:      aws.String(c.queueURL),
+		ReceiptHandle: message.ReceiptHandle,
+	})
+	if err != nil {
+		utils.Error("Failed to delete order event", map[string]interface{}{
+			"error":      err,
+			"message_id": aws.ToString(message.MessageId),
+		})
+	}
+}
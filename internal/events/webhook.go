@@ -0,0 +1,70 @@
+package events
+
+import (
+	"context"
+	"crypto/subtle"
+	"encoding/json"
+	"net/http"
+
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+)
+
+type webhookConsumer struct {
+	addr   string
+	secret string
+}
+
+// NewWebhookConsumer creates a consumer that receives order events over HTTP.
+// Callers must send secret in the X-Webhook-Secret header.
+func NewWebhookConsumer(addr string, secret string) Consumer {
+	return &webhookConsumer{
+		addr:   addr,
+		secret: secret,
+	}
+}
+
+func (c *webhookConsumer) Start(ctx context.Context, handler Handler) error {
+	mux := http.NewServeMux()
+	mux.HandleFunc("POST /events/orders", func(w http.ResponseWriter, r *http.Request) {
+		signature := r.Header.Get("X-Webhook-Secret")
+		if c.secret == "" || signature == "" || subtle.ConstantTimeCompare([]byte(signature), []byte(c.secret)) != 1 {
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+
+		var event OrderEvent
+		if err := json.NewDecoder(r.Body).Decode(&event); err != nil {
+			http.Error(w, "invalid event payload", http.StatusBadRequest)
+			return
+		}
+
+		if err := handler(r.Context(), event); err != nil {
+			utils.ErrorContext(r.Context(), "Failed to handle order event", map[string]interface{}{
+				"error":    err,
+				"event_id": event.ID,
+				"type":     event.Type,
+			})
+			if !Retryable(err) {
+				// A 4xx response tells the sender not to retry
+				http.Error(w, "invalid event", http.StatusBadRequest)
+				return
+			}
+			// A 5xx response tells the sender to retry
+			http.Error(w, "failed to handle event", http.StatusInternalServerError)
+			return
+		}
+
+		w.WriteHeader(http.StatusAccepted)
+	})
+
+	server := &http.Server{Addr: c.addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
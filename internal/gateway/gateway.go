@@ -0,0 +1,153 @@
+package gateway
+
+import (
+	"context"
+	"net/http"
+	"strings"
+	"sync/atomic"
+
+	"github.com/PharmaKart/reminder-svc/internal/auth"
+	"github.com/PharmaKart/reminder-svc/internal/proto"
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"go.opentelemetry.io/contrib/instrumentation/google.golang.org/grpc/otelgrpc"
+	"google.golang.org/grpc"
+	"google.golang.org/protobuf/encoding/protojson"
+)
+
+const requestIDHeader = "x-request-id"
+
+// forwardedHeaders are the HTTP headers passed on to the gRPC server as metadata
+var forwardedHeaders = map[string]bool{
+	requestIDHeader: true,
+}
+
+// strippedMetadata are metadata keys HTTP clients may not set through
+// Grpc-Metadata- headers. The caller is only identified by the Authorization
+// header, which the gateway passes on as authorization metadata for the
+// server to verify.
+var strippedMetadata = map[string]bool{
+	auth.AuthorizationHeader: true,
+	auth.UserIDHeader:        true,
+	auth.UserRoleHeader:      true,
+}
+
+// Serve runs the HTTP/JSON gateway on addr until ctx is cancelled. Calls are
+// forwarded to the gRPC server at grpcAddr, dialed with opts, so they go
+// through the same interceptors as direct gRPC calls.
+func Serve(ctx context.Context, addr, grpcAddr string, watchConns int, opts ...grpc.DialOption) error {
+	handler, err := NewHandler(ctx, grpcAddr, watchConns, opts...)
+	if err != nil {
+		return err
+	}
+
+	server := &http.Server{Addr: addr, Handler: handler}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
+
+// NewHandler serves the ReminderService API as JSON over HTTP and its OpenAPI
+// description at /openapi.json. Watch streams are spread over watchConns
+// connections of their own, so long-lived watches cannot use up the stream
+// limit of the connection other calls go over. The connections to grpcAddr
+// are closed when ctx is cancelled.
+func NewHandler(ctx context.Context, grpcAddr string, watchConns int, opts ...grpc.DialOption) (http.Handler, error) {
+	gatewayMux := runtime.NewServeMux(
+		// Field names match reminder.proto and the OpenAPI description
+		runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+			MarshalOptions: protojson.MarshalOptions{
+				UseProtoNames:   true,
+				EmitUnpopulated: true,
+			},
+			UnmarshalOptions: protojson.UnmarshalOptions{
+				DiscardUnknown: true,
+			},
+		}),
+		runtime.WithIncomingHeaderMatcher(incomingHeader),
+		runtime.WithOutgoingHeaderMatcher(outgoingHeader),
+	)
+
+	opts = append(opts, grpc.WithStatsHandler(otelgrpc.NewClientHandler()))
+	conn, err := dial(ctx, grpcAddr, opts)
+	if err != nil {
+		return nil, err
+	}
+	client := &watchClient{ReminderServiceClient: proto.NewReminderServiceClient(conn)}
+	for i := 0; i < max(watchConns, 1); i++ {
+		conn, err := dial(ctx, grpcAddr, opts)
+		if err != nil {
+			return nil, err
+		}
+		client.watchers = append(client.watchers, proto.NewReminderServiceClient(conn))
+	}
+
+	if err := proto.RegisterReminderServiceHandlerClient(ctx, gatewayMux, client); err != nil {
+		return nil, err
+	}
+
+	mux := http.NewServeMux()
+	mux.HandleFunc("GET /openapi.json", serveOpenAPI)
+	mux.Handle("/", gatewayMux)
+	return mux, nil
+}
+
+// dial connects to grpcAddr, closing the connection when ctx is cancelled
+func dial(ctx context.Context, grpcAddr string, opts []grpc.DialOption) (*grpc.ClientConn, error) {
+	conn, err := grpc.NewClient(grpcAddr, opts...)
+	if err != nil {
+		return nil, err
+	}
+
+	go func() {
+		<-ctx.Done()
+		conn.Close()
+	}()
+	return conn, nil
+}
+
+// watchClient sends watch streams round robin over their own connections, and
+// every other call over the embedded client
+type watchClient struct {
+	proto.ReminderServiceClient
+	watchers []proto.ReminderServiceClient
+	next     atomic.Uint32
+}
+
+func (c *watchClient) WatchReminderEvents(ctx context.Context, in *proto.WatchReminderEventsRequest, opts ...grpc.CallOption) (grpc.ServerStreamingClient[proto.ReminderEvent], error) {
+	n := c.next.Add(1)
+	return c.watchers[int(n%uint32(len(c.watchers)))].WatchReminderEvents(ctx, in, opts...)
+}
+
+func serveOpenAPI(w http.ResponseWriter, r *http.Request) {
+	w.Header().Set("Content-Type", "application/json")
+	w.Write(proto.OpenAPISpec)
+}
+
+// incomingHeader forwards the request ID along with the headers the gateway
+// forwards by default, except identity headers
+func incomingHeader(key string) (string, bool) {
+	if forwardedHeaders[strings.ToLower(key)] {
+		return strings.ToLower(key), true
+	}
+
+	name, ok := runtime.DefaultHeaderMatcher(key)
+	if ok && strippedMetadata[strings.ToLower(name)] {
+		return "", false
+	}
+	return name, ok
+}
+
+// outgoingHeader returns the request ID as is; other response metadata keeps
+// the gateway's Grpc-Metadata- prefix
+func outgoingHeader(key string) (string, bool) {
+	if key == requestIDHeader {
+		return "X-Request-Id", true
+	}
+	return runtime.MetadataHeaderPrefix + key, true
+}
@@ -0,0 +1,178 @@
+package handlers
+
+import (
+	"context"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/auth"
+	"github.com/PharmaKart/reminder-svc/internal/metrics"
+	"github.com/PharmaKart/reminder-svc/internal/proto"
+	"github.com/PharmaKart/reminder-svc/internal/requestid"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"github.com/google/uuid"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+)
+
+const requestIDHeader = "x-request-id"
+
+// PrincipalInterceptor stores the caller verified by authenticator in the
+// context, and rejects calls whose credentials do not verify
+func PrincipalInterceptor(authenticator *auth.Authenticator) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		principal, err := authenticator.Authenticate(ctx)
+		if err != nil {
+			return nil, status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(auth.NewContext(ctx, principal), req)
+	}
+}
+
+// PrincipalStreamInterceptor is PrincipalInterceptor for streaming RPCs
+func PrincipalStreamInterceptor(authenticator *auth.Authenticator) grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := ss.Context()
+		principal, err := authenticator.Authenticate(ctx)
+		if err != nil {
+			return status.Error(codes.Unauthenticated, err.Error())
+		}
+		return handler(srv, &contextStream{ss, auth.NewContext(ctx, principal)})
+	}
+}
+
+// contextStream replaces the context of a server stream
+type contextStream struct {
+	grpc.ServerStream
+	ctx context.Context
+}
+
+func (s *contextStream) Context() context.Context {
+	return s.ctx
+}
+
+// requestContext stores the request ID in the context, tags the context logger
+// with it, the method and the caller, and echoes it back in the response header
+func requestContext(ctx context.Context, method string) context.Context {
+	requestID := ""
+	if md, ok := metadata.FromIncomingContext(ctx); ok {
+		if values := md.Get(requestIDHeader); len(values) > 0 {
+			requestID = values[0]
+		}
+	}
+	if requestID == "" {
+		requestID = uuid.NewString()
+	}
+	grpc.SetHeader(ctx, metadata.Pairs(requestIDHeader, requestID))
+
+	return utils.ContextWithLogFields(requestid.NewContext(ctx, requestID), map[string]interface{}{
+		"request_id": requestID,
+		"method":     method,
+		"principal":  auth.FromContext(ctx).String(),
+	})
+}
+
+// responseWithError is a response that reports failures in its error field
+type responseWithError interface {
+	GetError() *proto.Error
+}
+
+// rpcCode is the status code of a finished RPC. Most handlers report failures
+// in the response's error field with an OK status, so those are mapped from
+// the application error type.
+func rpcCode(resp interface{}, err error) codes.Code {
+	if err != nil {
+		return status.Code(err)
+	}
+	if r, ok := resp.(responseWithError); ok && r.GetError() != nil {
+		return errorCode(errors.ErrorType(r.GetError().GetType()))
+	}
+	return codes.OK
+}
+
+// logRPC logs a finished RPC with its status and duration
+func logRPC(ctx context.Context, start time.Time, code codes.Code, err error) {
+	fields := map[string]interface{}{
+		"code":        code.String(),
+		"duration_ms": time.Since(start).Milliseconds(),
+	}
+	if err != nil {
+		fields["error"] = err.Error()
+	}
+
+	if code == codes.OK {
+		utils.InfoContext(ctx, "RPC completed", fields)
+	} else {
+		utils.WarnContext(ctx, "RPC failed", fields)
+	}
+}
+
+// LoggingInterceptor tags the context logger with a request ID, the method and
+// the caller, and logs every RPC with its duration. The request ID is taken
+// from the x-request-id metadata when the caller sets one, and echoed back in
+// the response header.
+func LoggingInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		ctx = requestContext(ctx, info.FullMethod)
+
+		start := time.Now()
+		resp, err := handler(ctx, req)
+		logRPC(ctx, start, rpcCode(resp, err), err)
+		return resp, err
+	}
+}
+
+// LoggingStreamInterceptor is LoggingInterceptor for streaming RPCs. The RPC
+// is logged when the stream ends.
+func LoggingStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		ctx := requestContext(ss.Context(), info.FullMethod)
+
+		start := time.Now()
+		err := handler(srv, &contextStream{ss, ctx})
+		logRPC(ctx, start, status.Code(err), err)
+		return err
+	}
+}
+
+// MetricsInterceptor counts every RPC and records its latency
+func MetricsInterceptor() grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		start := time.Now()
+		resp, err := handler(ctx, req)
+
+		metrics.RPCDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		metrics.RPCRequests.WithLabelValues(info.FullMethod, rpcCode(resp, err).String()).Inc()
+		return resp, err
+	}
+}
+
+// MetricsStreamInterceptor is MetricsInterceptor for streaming RPCs. Watch
+// streams stay open for hours, so their duration is kept apart from the RPC
+// latency histogram.
+func MetricsStreamInterceptor() grpc.StreamServerInterceptor {
+	return func(srv interface{}, ss grpc.ServerStream, info *grpc.StreamServerInfo, handler grpc.StreamHandler) error {
+		start := time.Now()
+		err := handler(srv, ss)
+
+		metrics.StreamDuration.WithLabelValues(info.FullMethod).Observe(time.Since(start).Seconds())
+		metrics.RPCRequests.WithLabelValues(info.FullMethod, status.Code(err).String()).Inc()
+		return err
+	}
+}
+
+// TimeoutInterceptor bounds every RPC, and the queries it runs, to timeout. A
+// shorter client deadline still wins.
+func TimeoutInterceptor(timeout time.Duration) grpc.UnaryServerInterceptor {
+	return func(ctx context.Context, req interface{}, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (interface{}, error) {
+		if timeout <= 0 {
+			return handler(ctx, req)
+		}
+
+		ctx, cancel := context.WithTimeout(ctx, timeout)
+		defer cancel()
+		return handler(ctx, req)
+	}
+}
@@ -2,7 +2,12 @@ package handlers
 
 import (
 	"context"
+	"strings"
+	"time"
 
+	"github.com/PharmaKart/reminder-svc/internal/dispatcher"
+	"github.com/PharmaKart/reminder-svc/internal/encryption"
+	"github.com/PharmaKart/reminder-svc/internal/eventbus"
 	"github.com/PharmaKart/reminder-svc/internal/models"
 	"github.com/PharmaKart/reminder-svc/internal/proto"
 	"github.com/PharmaKart/reminder-svc/internal/repositories"
@@ -11,6 +16,8 @@ import (
 	"github.com/PharmaKart/reminder-svc/pkg/errors"
 	"github.com/PharmaKart/reminder-svc/pkg/utils"
 	"github.com/robfig/cron/v3"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
 )
 
 type ReminderHandler interface {
@@ -21,21 +28,61 @@ type ReminderHandler interface {
 	DeleteReminder(ctx context.Context, req *proto.DeleteReminderRequest) (*proto.DeleteReminderResponse, error)
 	ToggleReminder(ctx context.Context, req *proto.ToggleReminderRequest) (*proto.ToggleReminderResponse, error)
 	ListReminderLogs(ctx context.Context, req *proto.ListReminderLogsRequest) (*proto.ListReminderLogsResponse, error)
+	OrderPlaced(ctx context.Context, req *proto.OrderPlacedRequest) (*proto.OrderPlacedResponse, error)
+	ListDeadLetteredReminders(ctx context.Context, req *proto.ListDeadLetteredRemindersRequest) (*proto.ListRemindersResponse, error)
+	RequeueReminder(ctx context.Context, req *proto.RequeueReminderRequest) (*proto.RequeueReminderResponse, error)
+	ReportDeliveryStatus(ctx context.Context, req *proto.ReportDeliveryStatusRequest) (*proto.ReportDeliveryStatusResponse, error)
+	AddSuppression(ctx context.Context, req *proto.AddSuppressionRequest) (*proto.AddSuppressionResponse, error)
+	RemoveSuppression(ctx context.Context, req *proto.RemoveSuppressionRequest) (*proto.RemoveSuppressionResponse, error)
+	ListSuppressions(ctx context.Context, req *proto.ListSuppressionsRequest) (*proto.ListSuppressionsResponse, error)
+	RecordConsent(ctx context.Context, req *proto.RecordConsentRequest) (*proto.RecordConsentResponse, error)
+	GetConsentHistory(ctx context.Context, req *proto.GetConsentHistoryRequest) (*proto.GetConsentHistoryResponse, error)
+	ExportConsents(ctx context.Context, req *proto.ExportConsentsRequest) (*proto.ExportConsentsResponse, error)
+	Unsubscribe(ctx context.Context, req *proto.UnsubscribeRequest) (*proto.UnsubscribeResponse, error)
+	GetReminderPreferences(ctx context.Context, req *proto.GetReminderPreferencesRequest) (*proto.ReminderPreferencesResponse, error)
+	UpdateReminderPreferences(ctx context.Context, req *proto.UpdateReminderPreferencesRequest) (*proto.ReminderPreferencesResponse, error)
+	GetDispatchStatus(ctx context.Context, req *proto.GetDispatchStatusRequest) (*proto.GetDispatchStatusResponse, error)
+	ListAuditEvents(ctx context.Context, req *proto.ListAuditEventsRequest) (*proto.ListAuditEventsResponse, error)
+	ListAllReminderLogs(ctx context.Context, req *proto.ListAllReminderLogsRequest) (*proto.ListReminderLogsResponse, error)
+	GetReminder(ctx context.Context, req *proto.GetReminderRequest) (*proto.GetReminderResponse, error)
+	WatchReminderEvents(req *proto.WatchReminderEventsRequest, stream proto.ReminderService_WatchReminderEventsServer) error
 }
 
 type reminderHandler struct {
 	proto.UnimplementedReminderServiceServer
-	reminderService services.ReminderService
+	reminderService    services.ReminderService
+	suppressionService services.SuppressionService
+	consentService     services.ConsentService
+	preferenceService  services.PreferenceService
+	auditService       services.AuditService
+	outboxRelay        services.OutboxRelay
 }
 
-func NewReminderHandler(reminderRepo repositories.ReminderRepository, reminderLogRepo repositories.ReminderLogRepository) *reminderHandler {
+func NewReminderHandler(cfg *config.Config, reminderService services.ReminderService, outboxRepo repositories.OutboxRepository, suppressionRepo repositories.SuppressionRepository, consentRepo repositories.ConsentRepository, preferenceRepo repositories.PreferenceRepository, auditRepo repositories.AuditRepository, transactor repositories.Transactor, dispatcher dispatcher.Dispatcher, retryPolicy services.RetryPolicy, cipher *encryption.Cipher, bus eventbus.Bus) *reminderHandler {
 	return &reminderHandler{
-		reminderService: services.NewReminderService(reminderRepo, reminderLogRepo),
+		reminderService:    reminderService,
+		suppressionService: services.NewSuppressionService(suppressionRepo, auditRepo, transactor),
+		consentService:     services.NewConsentService(consentRepo, auditRepo, transactor, cfg.UNSUBSCRIBE_SECRET),
+		preferenceService:  services.NewPreferenceService(preferenceRepo),
+		auditService:       services.NewAuditService(auditRepo),
+		outboxRelay:        services.NewOutboxRelay(outboxRepo, dispatcher, retryPolicy, cipher, bus),
 	}
 }
 
+// channelFromProto maps CHANNEL_EMAIL to "email"
+func channelFromProto(channel proto.Channel) string {
+	if channel == proto.Channel_CHANNEL_UNSPECIFIED {
+		return ""
+	}
+	return strings.ToLower(strings.TrimPrefix(channel.String(), "CHANNEL_"))
+}
+
+func channelToProto(channel string) proto.Channel {
+	return proto.Channel(proto.Channel_value["CHANNEL_"+strings.ToUpper(channel)])
+}
+
 func (h *reminderHandler) ScheduleReminder(ctx context.Context, req *proto.ScheduleReminderRequest) (*proto.ScheduleReminderResponse, error) {
-	err := h.reminderService.ScheduleReminder(req.CustomerId, req.OrderId, req.ProductId, req.ReminderDate)
+	err := h.reminderService.ScheduleReminder(ctx, req.CustomerId, req.OrderId, req.ProductId, req.ReminderDate)
 	if err != nil {
 		if appErr, ok := errors.IsAppError(err); ok {
 			return &proto.ScheduleReminderResponse{
@@ -70,7 +117,7 @@ func (h *reminderHandler) ListReminders(ctx context.Context, req *proto.ListRemi
 			Value:    req.Filter.Value,
 		}
 	}
-	reminders, total, err := h.reminderService.ListReminders(filter, req.SortBy, req.SortOrder, req.Page, req.Limit)
+	reminders, total, err := h.reminderService.ListReminders(ctx, filter, req.SortBy, req.SortOrder, req.Page, req.Limit)
 	if err != nil {
 		if appErr, ok := errors.IsAppError(err); ok {
 			return &proto.ListRemindersResponse{
@@ -100,6 +147,12 @@ func (h *reminderHandler) ListReminders(ctx context.Context, req *proto.ListRemi
 			ReminderDate: reminder.ReminderDate.Format("2006-01-02"),
 			LastSentAt:   reminder.LastSentAt.Format("2006-01-02"),
 			Enabled:      reminder.Enabled,
+			Status:       reminder.Status,
+			Attempts:     int32(reminder.Attempts),
+			LastError:    reminder.LastError,
+		}
+		if reminder.NextAttemptAt != nil {
+			protoReminders[i].NextAttemptAt = reminder.NextAttemptAt.Format(time.RFC3339)
 		}
 	}
 
@@ -121,7 +174,7 @@ func (h *reminderHandler) ListCustomerReminders(ctx context.Context, req *proto.
 			Value:    req.Filter.Value,
 		}
 	}
-	reminders, total, err := h.reminderService.ListCustomerReminders(req.CustomerId, filter, req.SortBy, req.SortOrder, req.Page, req.Limit)
+	reminders, total, err := h.reminderService.ListCustomerReminders(ctx, req.CustomerId, filter, req.SortBy, req.SortOrder, req.Page, req.Limit)
 	if err != nil {
 		if appErr, ok := errors.IsAppError(err); ok {
 			return &proto.ListRemindersResponse{
@@ -151,6 +204,12 @@ func (h *reminderHandler) ListCustomerReminders(ctx context.Context, req *proto.
 			ReminderDate: reminder.ReminderDate.Format("2006-01-02"),
 			LastSentAt:   reminder.LastSentAt.Format("2006-01-02"),
 			Enabled:      reminder.Enabled,
+			Status:       reminder.Status,
+			Attempts:     int32(reminder.Attempts),
+			LastError:    reminder.LastError,
+		}
+		if reminder.NextAttemptAt != nil {
+			protoReminders[i].NextAttemptAt = reminder.NextAttemptAt.Format(time.RFC3339)
 		}
 	}
 
@@ -164,7 +223,7 @@ func (h *reminderHandler) ListCustomerReminders(ctx context.Context, req *proto.
 }
 
 func (h *reminderHandler) UpdateReminder(ctx context.Context, req *proto.UpdateReminderRequest) (*proto.UpdateReminderResponse, error) {
-	err := h.reminderService.UpdateReminder(req.ReminderId, req.CustomerId, req.OrderId, req.ReminderDate)
+	err := h.reminderService.UpdateReminder(ctx, req.ReminderId, req.OrderId, req.ReminderDate)
 	if err != nil {
 		if appErr, ok := errors.IsAppError(err); ok {
 			return &proto.UpdateReminderResponse{
@@ -191,7 +250,7 @@ func (h *reminderHandler) UpdateReminder(ctx context.Context, req *proto.UpdateR
 }
 
 func (h *reminderHandler) DeleteReminder(ctx context.Context, req *proto.DeleteReminderRequest) (*proto.DeleteReminderResponse, error) {
-	err := h.reminderService.DeleteReminder(req.ReminderId, req.CustomerId)
+	err := h.reminderService.DeleteReminder(ctx, req.ReminderId)
 	if err != nil {
 		if appErr, ok := errors.IsAppError(err); ok {
 			return &proto.DeleteReminderResponse{
@@ -218,7 +277,7 @@ func (h *reminderHandler) DeleteReminder(ctx context.Context, req *proto.DeleteR
 }
 
 func (h *reminderHandler) ToggleReminder(ctx context.Context, req *proto.ToggleReminderRequest) (*proto.ToggleReminderResponse, error) {
-	err := h.reminderService.ToggleReminder(req.ReminderId, req.ReminderId)
+	err := h.reminderService.ToggleReminder(ctx, req.ReminderId)
 	if err != nil {
 		if appErr, ok := errors.IsAppError(err); ok {
 			return &proto.ToggleReminderResponse{
@@ -244,6 +303,131 @@ func (h *reminderHandler) ToggleReminder(ctx context.Context, req *proto.ToggleR
 	}, nil
 }
 
+func (h *reminderHandler) GetReminder(ctx context.Context, req *proto.GetReminderRequest) (*proto.GetReminderResponse, error) {
+	details, err := h.reminderService.GetReminder(ctx, req.ReminderId, req.LogLimit)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.GetReminderResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.GetReminderResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	reminder := details.Reminder
+	protoReminder := &proto.Reminder{
+		Id:           reminder.ID.String(),
+		CustomerId:   reminder.CustomerID.String(),
+		OrderId:      reminder.OrderID.String(),
+		ProductId:    reminder.ProductID.String(),
+		ReminderDate: reminder.ReminderDate.Format(time.RFC3339),
+		Enabled:      reminder.Enabled,
+		CreatedAt:    reminder.CreatedAt.Format(time.RFC3339),
+		Status:       reminder.Status,
+		Attempts:     int32(reminder.Attempts),
+		LastError:    reminder.LastError,
+	}
+	if !reminder.LastSentAt.IsZero() {
+		protoReminder.LastSentAt = reminder.LastSentAt.Format(time.RFC3339)
+	}
+	if reminder.NextAttemptAt != nil {
+		protoReminder.NextAttemptAt = reminder.NextAttemptAt.Format(time.RFC3339)
+	}
+
+	contact := &proto.ReminderContact{
+		Email: details.Email,
+	}
+	if details.Phone != nil {
+		contact.Phone = *details.Phone
+	}
+
+	response := &proto.GetReminderResponse{
+		Success:    true,
+		Reminder:   protoReminder,
+		Product:    details.Product,
+		Contact:    contact,
+		RecentLogs: reminderLogsToProto(details.RecentLogs),
+	}
+	if details.NextSendAt != nil {
+		response.NextSendAt = details.NextSendAt.Format(time.RFC3339)
+	}
+	return response, nil
+}
+
+// WatchReminderEvents streams reminder events until the client disconnects.
+// Streams of clients that fall behind, and every stream on shutdown, end with
+// UNAVAILABLE; clients should reconnect.
+func (h *reminderHandler) WatchReminderEvents(req *proto.WatchReminderEventsRequest, stream proto.ReminderService_WatchReminderEventsServer) error {
+	ctx := stream.Context()
+
+	events, cancel, err := h.reminderService.WatchReminderEvents(ctx, req.CustomerId, req.ReminderId)
+	if err != nil {
+		return streamError(err)
+	}
+	defer cancel()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case event, ok := <-events:
+			if !ok {
+				return status.Error(codes.Unavailable, "Reminder event stream closed, reconnect to resume")
+			}
+
+			err := stream.Send(&proto.ReminderEvent{
+				Type:       string(event.Type),
+				ReminderId: event.ReminderID,
+				CustomerId: event.CustomerID,
+				DigestId:   event.DigestID,
+				MessageId:  event.MessageID,
+				Status:     event.Status,
+				OccurredAt: event.OccurredAt.Format(time.RFC3339Nano),
+			})
+			if err != nil {
+				return err
+			}
+		}
+	}
+}
+
+// streamError maps an application error to a gRPC status, as streaming
+// responses have no error field
+func streamError(err error) error {
+	appErr, ok := errors.IsAppError(err)
+	if !ok {
+		return status.Error(codes.Internal, "An unexpected error occurred")
+	}
+	return status.Error(errorCode(appErr.Type), appErr.Message)
+}
+
+// errorCode is the gRPC status code for an application error type
+func errorCode(errorType errors.ErrorType) codes.Code {
+	switch errorType {
+	case errors.ValidationError, errors.BadRequestError:
+		return codes.InvalidArgument
+	case errors.AuthError:
+		return codes.PermissionDenied
+	case errors.NotFoundError:
+		return codes.NotFound
+	case errors.ConflictError:
+		return codes.AlreadyExists
+	default:
+		return codes.Internal
+	}
+}
+
 func (h *reminderHandler) ListReminderLogs(ctx context.Context, req *proto.ListReminderLogsRequest) (*proto.ListReminderLogsResponse, error) {
 	var filter models.Filter
 	if req.Filter != nil {
@@ -253,7 +437,7 @@ func (h *reminderHandler) ListReminderLogs(ctx context.Context, req *proto.ListR
 			Value:    req.Filter.Value,
 		}
 	}
-	reminderLogs, total, err := h.reminderService.ListReminderLogs(req.ReminderId, req.CustomerId, filter, req.SortBy, req.SortOrder, req.Page, req.Limit)
+	reminderLogs, total, err := h.reminderService.ListReminderLogs(ctx, req.ReminderId, filter, req.SortBy, req.SortOrder, req.Page, req.Limit)
 	if err != nil {
 		if appErr, ok := errors.IsAppError(err); ok {
 			return &proto.ListReminderLogsResponse{
@@ -274,6 +458,55 @@ func (h *reminderHandler) ListReminderLogs(ctx context.Context, req *proto.ListR
 		}, nil
 	}
 
+	return &proto.ListReminderLogsResponse{
+		Success: true,
+		Logs:    reminderLogsToProto(reminderLogs),
+		Total:   total,
+		Page:    req.Page,
+		Limit:   req.Limit,
+	}, nil
+}
+
+func (h *reminderHandler) ListAllReminderLogs(ctx context.Context, req *proto.ListAllReminderLogsRequest) (*proto.ListReminderLogsResponse, error) {
+	var filter models.Filter
+	if req.Filter != nil {
+		filter = models.Filter{
+			Column:   req.Filter.Column,
+			Operator: req.Filter.Operator,
+			Value:    req.Filter.Value,
+		}
+	}
+	reminderLogs, total, err := h.reminderService.ListAllReminderLogs(ctx, req.CustomerId, filter, req.SortBy, req.SortOrder, req.Page, req.Limit)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.ListReminderLogsResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.ListReminderLogsResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	return &proto.ListReminderLogsResponse{
+		Success: true,
+		Logs:    reminderLogsToProto(reminderLogs),
+		Total:   total,
+		Page:    req.Page,
+		Limit:   req.Limit,
+	}, nil
+}
+
+func reminderLogsToProto(reminderLogs []models.ReminderLog) []*proto.ReminderLog {
 	protoReminderLogs := make([]*proto.ReminderLog, len(reminderLogs))
 	for i, reminderLog := range reminderLogs {
 		protoReminderLogs[i] = &proto.ReminderLog{
@@ -282,23 +515,531 @@ func (h *reminderHandler) ListReminderLogs(ctx context.Context, req *proto.ListR
 			OrderId:    reminderLog.OrderID.String(),
 			Status:     reminderLog.Status,
 			CreatedAt:  reminderLog.CreatedAt.Format("2006-01-02"),
+			MessageId:  reminderLog.MessageID,
+		}
+		if reminderLog.DigestID != nil {
+			protoReminderLogs[i].DigestId = reminderLog.DigestID.String()
 		}
 	}
+	return protoReminderLogs
+}
 
-	return &proto.ListReminderLogsResponse{
+func (h *reminderHandler) OrderPlaced(ctx context.Context, req *proto.OrderPlacedRequest) (*proto.OrderPlacedResponse, error) {
+	reminder, err := h.reminderService.OrderPlaced(ctx, req.CustomerId, req.OrderId, req.ProductId, req.SupplyDays)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.OrderPlacedResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.OrderPlacedResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	return &proto.OrderPlacedResponse{
+		Success:      true,
+		ReminderId:   reminder.ID.String(),
+		ReminderDate: reminder.ReminderDate.Format("2006-01-02"),
+	}, nil
+}
+
+func (h *reminderHandler) ListDeadLetteredReminders(ctx context.Context, req *proto.ListDeadLetteredRemindersRequest) (*proto.ListRemindersResponse, error) {
+	reminders, total, err := h.reminderService.ListDeadLetteredReminders(ctx, req.SortBy, req.SortOrder, req.Page, req.Limit)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.ListRemindersResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.ListRemindersResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	protoReminders := make([]*proto.Reminder, len(reminders))
+	for i, reminder := range reminders {
+		protoReminders[i] = &proto.Reminder{
+			Id:           reminder.ID.String(),
+			CustomerId:   reminder.CustomerID.String(),
+			OrderId:      reminder.OrderID.String(),
+			ReminderDate: reminder.ReminderDate.Format("2006-01-02"),
+			LastSentAt:   reminder.LastSentAt.Format("2006-01-02"),
+			Enabled:      reminder.Enabled,
+			Status:       reminder.Status,
+			Attempts:     int32(reminder.Attempts),
+			LastError:    reminder.LastError,
+		}
+		if reminder.NextAttemptAt != nil {
+			protoReminders[i].NextAttemptAt = reminder.NextAttemptAt.Format(time.RFC3339)
+		}
+	}
+
+	return &proto.ListRemindersResponse{
+		Success:   true,
+		Reminders: protoReminders,
+		Total:     total,
+		Page:      req.Page,
+		Limit:     req.Limit,
+	}, nil
+}
+
+func (h *reminderHandler) RequeueReminder(ctx context.Context, req *proto.RequeueReminderRequest) (*proto.RequeueReminderResponse, error) {
+	err := h.reminderService.RequeueReminder(ctx, req.ReminderId)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.RequeueReminderResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.RequeueReminderResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	return &proto.RequeueReminderResponse{
+		Success: true,
+	}, nil
+}
+
+func (h *reminderHandler) ReportDeliveryStatus(ctx context.Context, req *proto.ReportDeliveryStatusRequest) (*proto.ReportDeliveryStatusResponse, error) {
+	// DELIVERY_STATUS_BOUNCED is stored as "bounced"
+	status := strings.ToLower(strings.TrimPrefix(req.Status.String(), "DELIVERY_STATUS_"))
+
+	err := h.reminderService.ReportDeliveryStatus(ctx, req.MessageId, status, channelFromProto(req.Channel), req.Address, req.Provider, req.Detail, req.OccurredAt)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.ReportDeliveryStatusResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.ReportDeliveryStatusResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	return &proto.ReportDeliveryStatusResponse{
+		Success: true,
+	}, nil
+}
+
+func (h *reminderHandler) AddSuppression(ctx context.Context, req *proto.AddSuppressionRequest) (*proto.AddSuppressionResponse, error) {
+	err := h.suppressionService.AddSuppression(ctx, channelFromProto(req.Channel), req.Address, req.Reason)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.AddSuppressionResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.AddSuppressionResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	return &proto.AddSuppressionResponse{
+		Success: true,
+	}, nil
+}
+
+func (h *reminderHandler) RemoveSuppression(ctx context.Context, req *proto.RemoveSuppressionRequest) (*proto.RemoveSuppressionResponse, error) {
+	err := h.suppressionService.RemoveSuppression(ctx, channelFromProto(req.Channel), req.Address)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.RemoveSuppressionResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.RemoveSuppressionResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	return &proto.RemoveSuppressionResponse{
+		Success: true,
+	}, nil
+}
+
+func (h *reminderHandler) ListSuppressions(ctx context.Context, req *proto.ListSuppressionsRequest) (*proto.ListSuppressionsResponse, error) {
+	var filter models.Filter
+	if req.Filter != nil {
+		filter = models.Filter{
+			Column:   req.Filter.Column,
+			Operator: req.Filter.Operator,
+			Value:    req.Filter.Value,
+		}
+	}
+	suppressions, total, err := h.suppressionService.ListSuppressions(ctx, filter, req.SortBy, req.SortOrder, req.Page, req.Limit)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.ListSuppressionsResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.ListSuppressionsResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	protoSuppressions := make([]*proto.Suppression, len(suppressions))
+	for i, suppression := range suppressions {
+		protoSuppressions[i] = &proto.Suppression{
+			Id:        suppression.ID.String(),
+			Channel:   channelToProto(suppression.Channel),
+			Address:   suppression.Address,
+			Reason:    suppression.Reason,
+			Source:    suppression.Source,
+			CreatedAt: suppression.CreatedAt.Format("2006-01-02"),
+		}
+	}
+
+	return &proto.ListSuppressionsResponse{
+		Success:      true,
+		Suppressions: protoSuppressions,
+		Total:        total,
+		Page:         req.Page,
+		Limit:        req.Limit,
+	}, nil
+}
+
+func (h *reminderHandler) RecordConsent(ctx context.Context, req *proto.RecordConsentRequest) (*proto.RecordConsentResponse, error) {
+	err := h.consentService.RecordConsent(ctx, req.CustomerId, channelFromProto(req.Channel), req.Granted, req.Source, req.TextVersion, req.RecordedAt)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.RecordConsentResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.RecordConsentResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	return &proto.RecordConsentResponse{
+		Success: true,
+	}, nil
+}
+
+func (h *reminderHandler) GetConsentHistory(ctx context.Context, req *proto.GetConsentHistoryRequest) (*proto.GetConsentHistoryResponse, error) {
+	consents, err := h.consentService.GetConsentHistory(ctx, req.CustomerId, channelFromProto(req.Channel))
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.GetConsentHistoryResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.GetConsentHistoryResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	protoConsents := make([]*proto.Consent, len(consents))
+	for i, consent := range consents {
+		protoConsents[i] = &proto.Consent{
+			Id:          consent.ID.String(),
+			CustomerId:  consent.CustomerID.String(),
+			Channel:     channelToProto(consent.Channel),
+			Granted:     consent.Granted,
+			Source:      consent.Source,
+			TextVersion: consent.TextVersion,
+			RecordedAt:  consent.RecordedAt.Format(time.RFC3339),
+		}
+	}
+
+	return &proto.GetConsentHistoryResponse{
+		Success:  true,
+		Consents: protoConsents,
+	}, nil
+}
+
+func (h *reminderHandler) ExportConsents(ctx context.Context, req *proto.ExportConsentsRequest) (*proto.ExportConsentsResponse, error) {
+	consents, total, err := h.consentService.ExportConsents(ctx, req.CustomerId, req.From, req.To, req.Page, req.Limit)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.ExportConsentsResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.ExportConsentsResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	protoConsents := make([]*proto.Consent, len(consents))
+	for i, consent := range consents {
+		protoConsents[i] = &proto.Consent{
+			Id:          consent.ID.String(),
+			CustomerId:  consent.CustomerID.String(),
+			Channel:     channelToProto(consent.Channel),
+			Granted:     consent.Granted,
+			Source:      consent.Source,
+			TextVersion: consent.TextVersion,
+			RecordedAt:  consent.RecordedAt.Format(time.RFC3339),
+		}
+	}
+
+	return &proto.ExportConsentsResponse{
+		Success:  true,
+		Consents: protoConsents,
+		Total:    total,
+		Page:     req.Page,
+		Limit:    req.Limit,
+	}, nil
+}
+
+func (h *reminderHandler) Unsubscribe(ctx context.Context, req *proto.UnsubscribeRequest) (*proto.UnsubscribeResponse, error) {
+	err := h.consentService.Unsubscribe(ctx, req.Token)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.UnsubscribeResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.UnsubscribeResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	return &proto.UnsubscribeResponse{
 		Success: true,
-		Logs:    protoReminderLogs,
+	}, nil
+}
+
+func (h *reminderHandler) GetReminderPreferences(ctx context.Context, req *proto.GetReminderPreferencesRequest) (*proto.ReminderPreferencesResponse, error) {
+	preference, err := h.preferenceService.GetPreferences(ctx, req.CustomerId)
+	if err != nil {
+		return preferencesErrorResponse(err), nil
+	}
+
+	return &proto.ReminderPreferencesResponse{
+		Success:     true,
+		Preferences: preferencesToProto(preference),
+	}, nil
+}
+
+func (h *reminderHandler) UpdateReminderPreferences(ctx context.Context, req *proto.UpdateReminderPreferencesRequest) (*proto.ReminderPreferencesResponse, error) {
+	preference, err := h.preferenceService.UpdatePreferences(ctx, req.CustomerId, req.DigestEnabled)
+	if err != nil {
+		return preferencesErrorResponse(err), nil
+	}
+
+	return &proto.ReminderPreferencesResponse{
+		Success:     true,
+		Preferences: preferencesToProto(preference),
+	}, nil
+}
+
+// GetDispatchStatus reports when each dispatch job last ran
+func (h *reminderHandler) GetDispatchStatus(ctx context.Context, req *proto.GetDispatchStatusRequest) (*proto.GetDispatchStatusResponse, error) {
+	runs := h.reminderService.DispatchRuns(ctx)
+
+	protoRuns := make([]*proto.DispatchRun, len(runs))
+	for i, run := range runs {
+		protoRuns[i] = &proto.DispatchRun{
+			Kind:          run.Kind,
+			StartedAt:     run.StartedAt.Format(time.RFC3339),
+			Batches:       int32(run.Batches),
+			FailedBatches: int32(run.FailedBatches),
+		}
+		if !run.FinishedAt.IsZero() {
+			protoRuns[i].FinishedAt = run.FinishedAt.Format(time.RFC3339)
+		}
+	}
+
+	return &proto.GetDispatchStatusResponse{
+		Success: true,
+		Runs:    protoRuns,
+	}, nil
+}
+
+func (h *reminderHandler) ListAuditEvents(ctx context.Context, req *proto.ListAuditEventsRequest) (*proto.ListAuditEventsResponse, error) {
+	events, total, err := h.auditService.ListAuditEvents(ctx, req.ReminderId, req.CustomerId, req.Actor, req.From, req.To, req.Page, req.Limit)
+	if err != nil {
+		if appErr, ok := errors.IsAppError(err); ok {
+			return &proto.ListAuditEventsResponse{
+				Success: false,
+				Error: &proto.Error{
+					Type:    string(appErr.Type),
+					Message: appErr.Message,
+					Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+				},
+			}, nil
+		}
+		return &proto.ListAuditEventsResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(errors.InternalError),
+				Message: "An unexpected error occurred",
+			},
+		}, nil
+	}
+
+	protoEvents := make([]*proto.AuditEvent, len(events))
+	for i, event := range events {
+		protoEvents[i] = &proto.AuditEvent{
+			Id:        event.ID.String(),
+			Actor:     event.Actor,
+			ActorRole: event.ActorRole,
+			Action:    event.Action,
+			Before:    event.Before,
+			After:     event.After,
+			RequestId: event.RequestID,
+			CreatedAt: event.CreatedAt.Format(time.RFC3339),
+		}
+		if event.ReminderID != nil {
+			protoEvents[i].ReminderId = event.ReminderID.String()
+		}
+		if event.CustomerID != nil {
+			protoEvents[i].CustomerId = event.CustomerID.String()
+		}
+	}
+
+	return &proto.ListAuditEventsResponse{
+		Success: true,
+		Events:  protoEvents,
 		Total:   total,
 		Page:    req.Page,
 		Limit:   req.Limit,
 	}, nil
 }
 
-func (h *reminderHandler) StartReminderService(cfg *config.Config) {
-	c := cron.New()
+func preferencesToProto(preference *models.ReminderPreference) *proto.ReminderPreferences {
+	protoPreferences := &proto.ReminderPreferences{
+		CustomerId:    preference.CustomerID.String(),
+		DigestEnabled: preference.DigestEnabled,
+	}
+	if !preference.UpdatedAt.IsZero() {
+		protoPreferences.UpdatedAt = preference.UpdatedAt.Format(time.RFC3339)
+	}
+	return protoPreferences
+}
+
+func preferencesErrorResponse(err error) *proto.ReminderPreferencesResponse {
+	if appErr, ok := errors.IsAppError(err); ok {
+		return &proto.ReminderPreferencesResponse{
+			Success: false,
+			Error: &proto.Error{
+				Type:    string(appErr.Type),
+				Message: appErr.Message,
+				Details: utils.ConvertMapToKeyValuePairs(appErr.Details),
+			},
+		}
+	}
+	return &proto.ReminderPreferencesResponse{
+		Success: false,
+		Error: &proto.Error{
+			Type:    string(errors.InternalError),
+			Message: "An unexpected error occurred",
+		},
+	}
+}
+
+// StartReminderService schedules the dispatch, retry and relay jobs. A job
+// still running when it is next due skips that run. Stop the returned
+// scheduler to wait for a running job on shutdown, and cancel ctx to abort it.
+func (h *reminderHandler) StartReminderService(ctx context.Context, cfg *config.Config) *cron.Cron {
+	c := cron.New(cron.WithChain(cron.SkipIfStillRunning(cron.PrintfLogger(utils.Logger))))
 
-	_, err := c.AddFunc("0 0 * * *", func() {
-		h.reminderService.StartReminderService(cfg)
+	_, err := c.AddFunc(services.DispatchSchedule, func() {
+		h.reminderService.StartReminderService(ctx, cfg)
 	})
 
 	if err != nil {
@@ -307,5 +1048,26 @@ func (h *reminderHandler) StartReminderService(cfg *config.Config) {
 		})
 	}
 
+	_, err = c.AddFunc(cfg.RETRY_SCHEDULE, func() {
+		h.reminderService.RetryFailedReminders(ctx, cfg)
+	})
+
+	if err != nil {
+		utils.Error("Failed to schedule reminder retry job", map[string]interface{}{
+			"error": err,
+		})
+	}
+
+	_, err = c.AddFunc(cfg.OUTBOX_RELAY_SCHEDULE, func() {
+		h.outboxRelay.RelayPendingMessages(ctx)
+	})
+
+	if err != nil {
+		utils.Error("Failed to schedule outbox relay job", map[string]interface{}{
+			"error": err,
+		})
+	}
+
 	c.Start()
+	return c
 }
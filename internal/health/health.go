@@ -0,0 +1,132 @@
+package health
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"google.golang.org/grpc/health"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"gorm.io/gorm"
+)
+
+// LivenessService is the health service name liveness probes should check.
+// The empty name and the API's service name report readiness.
+const LivenessService = "liveness"
+
+var errNotMigrated = errors.New("database migrations have not been applied")
+
+// Checker keeps the standard gRPC health service up to date. Readiness needs a
+// reachable database with migrations applied, liveness needs the cron scheduler
+// to keep ticking.
+type Checker struct {
+	server    *health.Server
+	db        *gorm.DB
+	services  []string
+	interval  time.Duration
+	migrated  atomic.Bool
+	heartbeat atomic.Int64
+}
+
+// NewChecker creates a checker reporting readiness under the empty service name
+// and each of services
+func NewChecker(db *gorm.DB, interval time.Duration, services ...string) *Checker {
+	c := &Checker{
+		server:   health.NewServer(),
+		db:       db,
+		services: append([]string{""}, services...),
+		interval: interval,
+	}
+
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, healthpb.HealthCheckResponse_NOT_SERVING)
+	}
+	c.server.SetServingStatus(LivenessService, healthpb.HealthCheckResponse_NOT_SERVING)
+	return c
+}
+
+// Server returns the health service to register on the gRPC server
+func (c *Checker) Server() healthpb.HealthServer {
+	return c.server
+}
+
+// SetMigrated records that the database migrations were applied
+func (c *Checker) SetMigrated() {
+	c.migrated.Store(true)
+}
+
+// Heartbeat records that the cron scheduler is running. Schedule it as a cron
+// job more often than the check interval.
+func (c *Checker) Heartbeat() {
+	c.heartbeat.Store(time.Now().UnixNano())
+}
+
+// Start checks health every interval until ctx is done
+func (c *Checker) Start(ctx context.Context) {
+	c.check(ctx)
+
+	ticker := time.NewTicker(c.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			c.check(ctx)
+		}
+	}
+}
+
+// Shutdown reports every service as not serving, so traffic drains before the
+// server stops
+func (c *Checker) Shutdown() {
+	c.server.Shutdown()
+}
+
+func (c *Checker) check(ctx context.Context) {
+	readiness := healthpb.HealthCheckResponse_SERVING
+	if err := c.ready(ctx); err != nil {
+		utils.Warn("Readiness check failed", map[string]interface{}{
+			"error": err.Error(),
+		})
+		readiness = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+
+	for _, service := range c.services {
+		c.server.SetServingStatus(service, readiness)
+	}
+
+	liveness := healthpb.HealthCheckResponse_SERVING
+	if !c.alive() {
+		utils.Warn("Liveness check failed: cron scheduler is not running", nil)
+		liveness = healthpb.HealthCheckResponse_NOT_SERVING
+	}
+	c.server.SetServingStatus(LivenessService, liveness)
+}
+
+func (c *Checker) ready(ctx context.Context) error {
+	if !c.migrated.Load() {
+		return errNotMigrated
+	}
+
+	sqlDB, err := c.db.DB()
+	if err != nil {
+		return err
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, c.interval)
+	defer cancel()
+	return sqlDB.PingContext(ctx)
+}
+
+// alive reports whether the scheduler ticked within the last two intervals
+func (c *Checker) alive() bool {
+	last := c.heartbeat.Load()
+	if last == 0 {
+		return false
+	}
+	return time.Since(time.Unix(0, last)) < 2*c.interval
+}
@@ -0,0 +1,36 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"gorm.io/gorm"
+)
+
+const startKey = "metrics:start"
+
+// InstrumentDB records the duration of every query run through db
+func InstrumentDB(db *gorm.DB) error {
+	return utils.RegisterQueryCallbacks(db, "metrics", startTimer, observeQuery)
+}
+
+func startTimer(db *gorm.DB, operation string) {
+	db.InstanceSet(startKey, time.Now())
+}
+
+func observeQuery(db *gorm.DB, operation string) {
+	value, ok := db.InstanceGet(startKey)
+	if !ok {
+		return
+	}
+	start, ok := value.(time.Time)
+	if !ok {
+		return
+	}
+
+	table := db.Statement.Table
+	if table == "" {
+		table = "unknown"
+	}
+	QueryDuration.WithLabelValues(operation, table).Observe(time.Since(start).Seconds())
+}
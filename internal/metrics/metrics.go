@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/promauto"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+)
+
+const namespace = "reminder"
+
+// Reminder outcomes counted by RemindersTotal
+const (
+	OutcomeDue     = "due"
+	OutcomeQueued  = "queued"
+	OutcomeSkipped = "skipped"
+	OutcomeSent    = "sent"
+	OutcomeFailed  = "failed"
+)
+
+var (
+	RPCRequests = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "grpc_requests_total",
+		Help:      "gRPC requests handled, by method and status code.",
+	}, []string{"method", "code"})
+
+	RPCDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_request_duration_seconds",
+		Help:      "gRPC request latency, by method.",
+		Buckets:   prometheus.DefBuckets,
+	}, []string{"method"})
+
+	StreamDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "grpc_stream_duration_seconds",
+		Help:      "How long gRPC streams stay open, by method.",
+		Buckets:   []float64{1, 10, 60, 300, 900, 1800, 3600, 4 * 3600, 12 * 3600, 24 * 3600},
+	}, []string{"method"})
+
+	QueryDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "db_query_duration_seconds",
+		Help:      "Database query latency, by operation and table.",
+		Buckets:   []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5},
+	}, []string{"operation", "table"})
+
+	DispatchDuration = promauto.NewHistogramVec(prometheus.HistogramOpts{
+		Namespace: namespace,
+		Name:      "dispatch_run_duration_seconds",
+		Help:      "Duration of dispatch runs, by kind (pending or retryable).",
+		Buckets:   []float64{.1, .5, 1, 5, 15, 30, 60, 120, 300, 600, 1800},
+	}, []string{"kind"})
+
+	// RemindersTotal counts reminders as they move through dispatch. Due, queued
+	// and skipped count reminders; sent and failed count outgoing messages, so
+	// a digest counts once.
+	RemindersTotal = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "reminders_total",
+		Help:      "Reminders by dispatch outcome and channel.",
+	}, []string{"outcome", "channel"})
+
+	QueueLag = promauto.NewGauge(prometheus.GaugeOpts{
+		Namespace: namespace,
+		Name:      "queue_lag_seconds",
+		Help:      "Age of the oldest due reminder that has not been sent, measured at the start and end of each dispatch run.",
+	})
+
+	ThrottledMessages = promauto.NewCounterVec(prometheus.CounterOpts{
+		Namespace: namespace,
+		Name:      "dispatch_throttled_total",
+		Help:      "Outgoing messages deferred by a rate limit, by scope.",
+	}, []string{"scope"})
+)
+
+// Serve exposes the metrics on addr under /metrics until ctx is done
+func Serve(ctx context.Context, addr string) error {
+	mux := http.NewServeMux()
+	mux.Handle("GET /metrics", promhttp.Handler())
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		<-ctx.Done()
+		server.Shutdown(context.Background())
+	}()
+
+	if err := server.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return err
+	}
+	return nil
+}
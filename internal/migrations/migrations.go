@@ -0,0 +1,41 @@
+// Package migrations creates and updates this service's tables.
+package migrations
+
+import (
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"gorm.io/gorm"
+)
+
+// Run creates or updates the tables owned by this service
+func Run(db *gorm.DB) error {
+	err := db.AutoMigrate(
+		&models.Reminder{},
+		&models.ReminderOrder{},
+		&models.ReminderLog{},
+		&models.OutboxMessage{},
+		&models.DeliveryEvent{},
+		&models.Suppression{},
+		&models.Consent{},
+		&models.ReminderPreference{},
+		&models.AuditEvent{},
+	)
+	if err != nil {
+		return err
+	}
+
+	return db.Exec(auditAppendOnlySQL).Error
+}
+
+// auditAppendOnlySQL makes the audit trail append-only, even for direct SQL
+const auditAppendOnlySQL = `
+CREATE OR REPLACE FUNCTION reminder_audit_append_only() RETURNS trigger AS $$
+BEGIN
+	RAISE EXCEPTION 'reminder_audit is append-only';
+END;
+$$ LANGUAGE plpgsql;
+
+DROP TRIGGER IF EXISTS reminder_audit_append_only ON reminder_audit;
+CREATE TRIGGER reminder_audit_append_only
+	BEFORE UPDATE OR DELETE ON reminder_audit
+	FOR EACH ROW EXECUTE FUNCTION reminder_audit_append_only();
+`
@@ -0,0 +1,49 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	AuditActionReminderCreated     = "reminder.created"
+	AuditActionReminderUpdated     = "reminder.updated"
+	AuditActionReminderRescheduled = "reminder.rescheduled"
+	AuditActionReminderToggled     = "reminder.toggled"
+	AuditActionReminderDeleted     = "reminder.deleted"
+	AuditActionReminderDisabled    = "reminder.disabled"
+	AuditActionReminderRequeued    = "reminder.requeued"
+	AuditActionReminderLogsRead    = "reminder_logs.read"
+	AuditActionSuppressionAdded    = "suppression.added"
+	AuditActionSuppressionRemoved  = "suppression.removed"
+	AuditActionConsentRecorded     = "consent.recorded"
+)
+
+// AuditActorSystem is the actor of changes made by scheduled jobs and order events
+const AuditActorSystem = "system"
+
+// AuditEvent records who changed a reminder, or performed an admin action, and
+// how. Events are never updated or deleted.
+type AuditEvent struct {
+	ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ReminderID *uuid.UUID `gorm:"type:uuid;index"`
+	CustomerID *uuid.UUID `gorm:"type:uuid;index"`
+	Actor      string     `gorm:"not null;index"`
+	ActorRole  string
+	Action     string `gorm:"not null"`
+	Before     string `gorm:"type:jsonb"` // Fields the action changed, as they were before
+	After      string `gorm:"type:jsonb"` // The same fields after the action
+	RequestID  string
+	CreatedAt  time.Time `gorm:"type:timestamptz;default:now();index"`
+}
+
+func (AuditEvent) TableName() string {
+	return "reminder_audit"
+}
+
+func (a *AuditEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	a.ID = uuid.New()
+	return
+}
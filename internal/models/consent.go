@@ -0,0 +1,29 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const ConsentSourceUnsubscribeLink = "unsubscribe_link"
+
+// Consent records a customer granting or withdrawing consent to receive messages
+// on a channel. Records are never updated: the latest one per channel is the
+// customer's current consent.
+type Consent struct {
+	ID          uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CustomerID  uuid.UUID `gorm:"not null;index"`
+	Channel     string    `gorm:"not null"`
+	Granted     bool      `gorm:"not null"`
+	Source      string    `gorm:"not null"`
+	TextVersion string
+	RecordedAt  time.Time `gorm:"type:timestamptz;not null"`
+	CreatedAt   time.Time `gorm:"type:timestamptz;default:now()"`
+}
+
+func (c *Consent) BeforeCreate(tx *gorm.DB) (err error) {
+	c.ID = uuid.New()
+	return
+}
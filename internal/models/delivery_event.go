@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// DeliveryEvent is a status callback from a downstream sender for a reminder message
+type DeliveryEvent struct {
+	ID            uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ReminderLogID uuid.UUID `gorm:"not null;index"`
+	MessageID     string    `gorm:"not null;index"`
+	Status        string    `gorm:"not null"`
+	Provider      string
+	Detail        string    `gorm:"type:text"`
+	OccurredAt    time.Time `gorm:"type:timestamptz;not null"`
+	CreatedAt     time.Time `gorm:"type:timestamptz;default:now()"`
+}
+
+func (de *DeliveryEvent) BeforeCreate(tx *gorm.DB) (err error) {
+	de.ID = uuid.New()
+	return
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	OutboxStatusPending      = "pending"
+	OutboxStatusSent         = "sent"
+	OutboxStatusDeadLettered = "dead_lettered"
+)
+
+// OutboxMessage is an outgoing reminder or digest message waiting to be published
+type OutboxMessage struct {
+	ID            uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ReminderID    *uuid.UUID `gorm:"type:uuid;index"` // Unset for digests
+	DigestID      *uuid.UUID `gorm:"type:uuid;index"`
+	CustomerID    uuid.UUID  `gorm:"type:uuid"`
+	Channels      string     // Comma-separated channels the message goes out on
+	DedupeKey     string     `gorm:"not null;uniqueIndex"`
+	Payload       string     `gorm:"type:text;not null"`
+	TraceParent   string     // W3C traceparent of the dispatch run that queued the message
+	Status        string     `gorm:"not null;default:pending;index"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt *time.Time `gorm:"type:timestamptz"`
+	LastError     string     `gorm:"type:text"`
+	SentAt        *time.Time `gorm:"type:timestamptz"`
+	CreatedAt     time.Time  `gorm:"type:timestamptz;default:now()"`
+}
+
+func (OutboxMessage) TableName() string {
+	return "outbox"
+}
+
+func (o *OutboxMessage) BeforeCreate(tx *gorm.DB) (err error) {
+	o.ID = uuid.New()
+	return
+}
@@ -0,0 +1,15 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+)
+
+// ReminderPreference holds a customer's delivery preferences. Customers without
+// a row get the defaults.
+type ReminderPreference struct {
+	CustomerID    uuid.UUID `gorm:"type:uuid;primaryKey"`
+	DigestEnabled bool      `gorm:"not null;default:false"`
+	UpdatedAt     time.Time `gorm:"type:timestamptz;default:now()"`
+}
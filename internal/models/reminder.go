@@ -7,15 +7,24 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	ReminderStatusActive       = "active"
+	ReminderStatusDeadLettered = "dead_lettered"
+)
+
 type Reminder struct {
-	ID           uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	CustomerID   uuid.UUID `gorm:"not null"`
-	OrderID      uuid.UUID `gorm:"not null"`
-	ProductID    uuid.UUID `gorm:"not null"`
-	ReminderDate time.Time `gorm:"type:timestamptz;not null"`
-	LastSentAt   time.Time `gorm:"type:timestamptz;default:null"`
-	Enabled      bool      `gorm:"default:true"`
-	CreatedAt    time.Time `gorm:"type:timestamptz;default:now()"`
+	ID            uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	CustomerID    uuid.UUID  `gorm:"not null"`
+	OrderID       uuid.UUID  `gorm:"not null"`
+	ProductID     uuid.UUID  `gorm:"not null"`
+	ReminderDate  time.Time  `gorm:"type:timestamptz;not null"`
+	LastSentAt    time.Time  `gorm:"type:timestamptz;default:null"`
+	Enabled       bool       `gorm:"default:true"`
+	Status        string     `gorm:"not null;default:active"`
+	Attempts      int        `gorm:"not null;default:0"`
+	NextAttemptAt *time.Time `gorm:"type:timestamptz"`
+	LastError     string     `gorm:"type:text"`
+	CreatedAt     time.Time  `gorm:"type:timestamptz;default:now()"`
 }
 
 func (r *Reminder) BeforeCreate(tx *gorm.DB) (err error) {
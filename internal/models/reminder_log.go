@@ -7,12 +7,59 @@ import (
 	"gorm.io/gorm"
 )
 
+const (
+	ReminderLogStatusQueued     = "queued"
+	ReminderLogStatusSent       = "sent"
+	ReminderLogStatusDelivered  = "delivered"
+	ReminderLogStatusOpened     = "opened"
+	ReminderLogStatusBounced    = "bounced"
+	ReminderLogStatusFailed     = "failed"
+	ReminderLogStatusSuppressed = "suppressed"
+	ReminderLogStatusNoConsent  = "no_consent"
+)
+
+// DeliveryStatusOptedOut is reported when a recipient replies STOP or unsubscribes.
+// It suppresses the address instead of changing a log entry.
+const DeliveryStatusOptedOut = "opted_out"
+
+// reminderLogStatusRanks orders delivery statuses so late, duplicate or
+// out-of-order callbacks never move a log entry backwards or sideways
+var reminderLogStatusRanks = map[string]int{
+	ReminderLogStatusQueued:    0,
+	ReminderLogStatusSent:      1,
+	ReminderLogStatusDelivered: 2,
+	ReminderLogStatusBounced:   2,
+	ReminderLogStatusFailed:    2,
+	ReminderLogStatusOpened:    3,
+}
+
+// IsDeliveryStatus reports whether status can be reported by a downstream sender
+func IsDeliveryStatus(status string) bool {
+	switch status {
+	case ReminderLogStatusSent, ReminderLogStatusDelivered, ReminderLogStatusOpened, ReminderLogStatusBounced, ReminderLogStatusFailed:
+		return true
+	}
+	return false
+}
+
+// ReminderLogStatusAdvances reports whether moving from current to next moves
+// the log entry forward. Bounced and failed entries are final.
+func ReminderLogStatusAdvances(current, next string) bool {
+	switch current {
+	case ReminderLogStatusBounced, ReminderLogStatusFailed:
+		return false
+	}
+	return reminderLogStatusRanks[next] > reminderLogStatusRanks[current]
+}
+
 type ReminderLog struct {
-	ID         uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
-	ReminderID uuid.UUID `gorm:"not null"`
-	OrderID    uuid.UUID `gorm:"not null"`
-	Status     string    `gorm:"not null"`
-	CreatedAt  time.Time `gorm:"type:timestamptz;default:now()"`
+	ID         uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ReminderID uuid.UUID  `gorm:"not null"`
+	OrderID    uuid.UUID  `gorm:"not null"`
+	MessageID  string     `gorm:"index"`
+	DigestID   *uuid.UUID `gorm:"type:uuid;index"` // Shared by every reminder sent in the same digest
+	Status     string     `gorm:"not null"`
+	CreatedAt  time.Time  `gorm:"type:timestamptz;default:now()"`
 }
 
 func (rl *ReminderLog) BeforeCreate(tx *gorm.DB) (err error) {
@@ -0,0 +1,25 @@
+package models
+
+import (
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+// ReminderOrder is an order that created or advanced a reminder, with the
+// supply days it added to the reminder date. Cancelling the order takes them
+// back off.
+type ReminderOrder struct {
+	ID          uuid.UUID  `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	ReminderID  uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_reminder_orders_reminder_order"`
+	OrderID     uuid.UUID  `gorm:"type:uuid;not null;uniqueIndex:idx_reminder_orders_reminder_order;index"`
+	SupplyDays  int        `gorm:"not null;default:0"`
+	CancelledAt *time.Time `gorm:"type:timestamptz"`
+	CreatedAt   time.Time  `gorm:"type:timestamptz;default:now()"`
+}
+
+func (o *ReminderOrder) BeforeCreate(tx *gorm.DB) (err error) {
+	o.ID = uuid.New()
+	return
+}
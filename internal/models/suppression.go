@@ -0,0 +1,57 @@
+package models
+
+import (
+	"strings"
+	"time"
+
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+)
+
+const (
+	ChannelEmail = "email"
+	ChannelSMS   = "sms"
+)
+
+const (
+	SuppressionReasonHardBounce = "hard_bounce"
+	SuppressionReasonStopReply  = "stop_reply"
+	SuppressionReasonAdmin      = "admin"
+)
+
+const (
+	SuppressionSourceDeliveryCallback = "delivery_callback"
+	SuppressionSourceAdmin            = "admin"
+)
+
+// Suppression blocks every message to an address on a channel
+type Suppression struct {
+	ID        uuid.UUID `gorm:"type:uuid;default:uuid_generate_v4();primaryKey"`
+	Channel   string    `gorm:"not null;uniqueIndex:idx_suppressions_channel_address"`
+	Address   string    `gorm:"not null;uniqueIndex:idx_suppressions_channel_address"`
+	Reason    string    `gorm:"not null"`
+	Source    string    `gorm:"not null"`
+	CreatedAt time.Time `gorm:"type:timestamptz;default:now()"`
+}
+
+func (s *Suppression) BeforeCreate(tx *gorm.DB) (err error) {
+	s.ID = uuid.New()
+	return
+}
+
+// IsChannel reports whether channel is a supported messaging channel
+func IsChannel(channel string) bool {
+	return channel == ChannelEmail || channel == ChannelSMS
+}
+
+// NormalizeAddress puts an address in the form it is stored and matched in
+func NormalizeAddress(channel, address string) string {
+	address = strings.TrimSpace(address)
+	switch channel {
+	case ChannelEmail:
+		return strings.ToLower(address)
+	case ChannelSMS:
+		return strings.NewReplacer(" ", "", "-", "", "(", "", ")", "").Replace(address)
+	}
+	return address
+}
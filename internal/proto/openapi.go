@@ -0,0 +1,9 @@
+package proto
+
+import _ "embed"
+
+// OpenAPISpec is the OpenAPI v2 description of the HTTP/JSON gateway,
+// generated from the google.api.http annotations in reminder.proto
+//
+//go:embed reminder.swagger.json
+var OpenAPISpec []byte
@@ -0,0 +1,84 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"gorm.io/gorm"
+)
+
+// AuditRepository only appends to the audit trail. The table's trigger rejects
+// updates and deletes.
+type AuditRepository interface {
+	RecordAuditEvent(ctx context.Context, event *models.AuditEvent) error
+	ListAuditEvents(ctx context.Context, filter AuditEventFilter, page, limit int32) ([]models.AuditEvent, int32, error)
+}
+
+// AuditEventFilter narrows ListAuditEvents. Empty fields match every event.
+type AuditEventFilter struct {
+	ReminderID string
+	CustomerID string
+	Actor      string
+	From       *time.Time
+	To         *time.Time
+}
+
+type auditRepository struct {
+	db *gorm.DB
+}
+
+func NewAuditRepository(db *gorm.DB) AuditRepository {
+	return &auditRepository{db}
+}
+
+func (r *auditRepository) RecordAuditEvent(ctx context.Context, event *models.AuditEvent) error {
+	if err := conn(ctx, r.db).Create(event).Error; err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
+
+// ListAuditEvents returns matching audit events, newest first
+func (r *auditRepository) ListAuditEvents(ctx context.Context, filter AuditEventFilter, page, limit int32) ([]models.AuditEvent, int32, error) {
+	var events []models.AuditEvent
+	var total int64
+
+	query := conn(ctx, r.db).Clauses(utils.ReadReplica()).Model(&models.AuditEvent{})
+	if filter.ReminderID != "" {
+		query = query.Where("reminder_id = ?", filter.ReminderID)
+	}
+	if filter.CustomerID != "" {
+		query = query.Where("customer_id = ?", filter.CustomerID)
+	}
+	if filter.Actor != "" {
+		query = query.Where("actor = ?", filter.Actor)
+	}
+	if filter.From != nil {
+		query = query.Where("created_at >= ?", *filter.From)
+	}
+	if filter.To != nil {
+		query = query.Where("created_at < ?", *filter.To)
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, errors.NewInternalError(err)
+	}
+
+	query = query.Order("created_at desc")
+
+	if limit > 0 {
+		offset := max(int((page-1)*limit), 0)
+		query = query.Offset(offset).Limit(int(limit))
+	}
+
+	err = query.Find(&events).Error
+	if err != nil {
+		return nil, 0, errors.NewInternalError(err)
+	}
+
+	return events, int32(total), nil
+}
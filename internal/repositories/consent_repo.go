@@ -0,0 +1,105 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"gorm.io/gorm"
+)
+
+type ConsentRepository interface {
+	RecordConsent(ctx context.Context, consent *models.Consent) error
+	GetConsentHistory(ctx context.Context, customerID string, channel string) ([]models.Consent, error)
+	GetCurrentConsents(ctx context.Context, customerID string) (map[string]bool, error)
+	ExportConsents(ctx context.Context, customerID string, from, to *time.Time, page, limit int32) ([]models.Consent, int32, error)
+}
+
+type consentRepository struct {
+	db *gorm.DB
+}
+
+func NewConsentRepository(db *gorm.DB) ConsentRepository {
+	return &consentRepository{db}
+}
+
+func (r *consentRepository) RecordConsent(ctx context.Context, consent *models.Consent) error {
+	if err := conn(ctx, r.db).Create(consent).Error; err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
+
+// GetConsentHistory returns a customer's consent records, newest first. An empty
+// channel returns every channel.
+func (r *consentRepository) GetConsentHistory(ctx context.Context, customerID string, channel string) ([]models.Consent, error) {
+	var consents []models.Consent
+
+	query := conn(ctx, r.db).Where("customer_id = ?", customerID)
+	if channel != "" {
+		query = query.Where("channel = ?", channel)
+	}
+
+	if err := query.Order("recorded_at desc").Find(&consents).Error; err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return consents, nil
+}
+
+// GetCurrentConsents returns whether the customer's latest record grants consent, per channel
+func (r *consentRepository) GetCurrentConsents(ctx context.Context, customerID string) (map[string]bool, error) {
+	var consents []models.Consent
+
+	err := conn(ctx, r.db).
+		Select("DISTINCT ON (channel) *").
+		Where("customer_id = ?", customerID).
+		Order("channel, recorded_at desc").
+		Find(&consents).Error
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+
+	current := make(map[string]bool, len(consents))
+	for _, consent := range consents {
+		current[consent.Channel] = consent.Granted
+	}
+	return current, nil
+}
+
+// ExportConsents returns consent records for audits, oldest first
+func (r *consentRepository) ExportConsents(ctx context.Context, customerID string, from, to *time.Time, page, limit int32) ([]models.Consent, int32, error) {
+	var consents []models.Consent
+	var total int64
+
+	query := conn(ctx, r.db).Clauses(utils.ReadReplica()).Model(&models.Consent{})
+	if customerID != "" {
+		query = query.Where("customer_id = ?", customerID)
+	}
+	if from != nil {
+		query = query.Where("recorded_at >= ?", *from)
+	}
+	if to != nil {
+		query = query.Where("recorded_at < ?", *to)
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, errors.NewInternalError(err)
+	}
+
+	query = query.Order("recorded_at asc")
+
+	if limit > 0 {
+		offset := max(int((page-1)*limit), 0)
+		query = query.Offset(offset).Limit(int(limit))
+	}
+
+	err = query.Find(&consents).Error
+	if err != nil {
+		return nil, 0, errors.NewInternalError(err)
+	}
+
+	return consents, int32(total), nil
+}
@@ -0,0 +1,209 @@
+package repositories
+
+import (
+	"context"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository interface {
+	EnqueueBatch(ctx context.Context, entries []OutboxEntry) (int, error)
+	ClaimPendingMessages(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error)
+	MarkSent(ctx context.Context, message *models.OutboxMessage) error
+	MarkFailed(ctx context.Context, message *models.OutboxMessage, nextAttemptAt *time.Time, lastError string) error
+	DeferMessages(ctx context.Context, messages []models.OutboxMessage, until time.Time) error
+}
+
+type outboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *gorm.DB) OutboxRepository {
+	return &outboxRepository{db}
+}
+
+// OutboxEntry is an outgoing message together with the reminders it carries
+type OutboxEntry struct {
+	Reminders []*models.Reminder
+	Message   *models.OutboxMessage
+}
+
+// EnqueueBatch stores each outgoing message, a log entry per reminder and the
+// reminders' LastSentAt in one transaction, so either the whole batch is
+// enqueued or none of it is. Messages whose dedupe key was already enqueued are
+// skipped. It returns the number of messages enqueued.
+func (r *outboxRepository) EnqueueBatch(ctx context.Context, entries []OutboxEntry) (int, error) {
+	enqueued := 0
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		enqueued = 0
+		for _, entry := range entries {
+			ok, err := enqueue(tx, entry)
+			if err != nil {
+				return err
+			}
+			if ok {
+				enqueued++
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return 0, errors.NewInternalError(err)
+	}
+	return enqueued, nil
+}
+
+func enqueue(tx *gorm.DB, entry OutboxEntry) (bool, error) {
+	result := tx.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "dedupe_key"}},
+		DoNothing: true,
+	}).Create(entry.Message)
+	if result.Error != nil {
+		return false, result.Error
+	}
+	if result.RowsAffected == 0 {
+		return false, nil
+	}
+
+	reminderIDs := make([]uuid.UUID, 0, len(entry.Reminders))
+	for _, reminder := range entry.Reminders {
+		reminderLog := &models.ReminderLog{
+			ReminderID: reminder.ID,
+			OrderID:    reminder.OrderID,
+			MessageID:  entry.Message.DedupeKey,
+			DigestID:   entry.Message.DigestID,
+			Status:     models.ReminderLogStatusQueued,
+		}
+		if err := tx.Create(reminderLog).Error; err != nil {
+			return false, err
+		}
+		reminderIDs = append(reminderIDs, reminder.ID)
+	}
+
+	err := tx.Model(&models.Reminder{}).Where("id IN ?", reminderIDs).Updates(map[string]interface{}{
+		"last_sent_at":    time.Now(),
+		"attempts":        0,
+		"next_attempt_at": nil,
+		"last_error":      "",
+	}).Error
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+// ClaimPendingMessages claims up to limit due messages for one relay run by
+// pushing their next attempt out by lease. Rows locked by another run are
+// skipped, so overlapping runs and replicas never claim the same message. A
+// claim that MarkSent, MarkFailed or DeferMessages does not settle expires
+// after lease, and the message is claimed again.
+func (r *outboxRepository) ClaimPendingMessages(ctx context.Context, limit int, lease time.Duration) ([]models.OutboxMessage, error) {
+	var messages []models.OutboxMessage
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		now := time.Now()
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+			Where("status = ?", models.OutboxStatusPending).
+			Where("(next_attempt_at IS NULL OR next_attempt_at <= ?)", now).
+			Order("created_at asc").
+			Limit(limit).
+			Find(&messages).Error
+		if err != nil || len(messages) == 0 {
+			return err
+		}
+
+		return tx.Model(&models.OutboxMessage{}).Where("id IN ?", messageIDs(messages)).Update("next_attempt_at", now.Add(lease)).Error
+	})
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return messages, nil
+}
+
+func (r *outboxRepository) MarkSent(ctx context.Context, message *models.OutboxMessage) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		err := tx.Model(&models.OutboxMessage{}).Where("id = ?", message.ID).Updates(map[string]interface{}{
+			"status":  models.OutboxStatusSent,
+			"sent_at": time.Now(),
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Model(&models.ReminderLog{}).
+			Where("message_id = ? AND status = ?", message.DedupeKey, models.ReminderLogStatusQueued).
+			Update("status", models.ReminderLogStatusSent).Error
+	})
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
+
+// MarkFailed stores a failed send. A nil nextAttemptAt dead-letters the message
+// together with every reminder it carries.
+func (r *outboxRepository) MarkFailed(ctx context.Context, message *models.OutboxMessage, nextAttemptAt *time.Time, lastError string) error {
+	attempts := message.Attempts + 1
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		status := models.OutboxStatusPending
+		if nextAttemptAt == nil {
+			status = models.OutboxStatusDeadLettered
+		}
+
+		err := tx.Model(&models.OutboxMessage{}).Where("id = ?", message.ID).Updates(map[string]interface{}{
+			"status":          status,
+			"attempts":        attempts,
+			"next_attempt_at": nextAttemptAt,
+			"last_error":      lastError,
+		}).Error
+		if err != nil || nextAttemptAt != nil {
+			return err
+		}
+
+		err = tx.Model(&models.ReminderLog{}).
+			Where("message_id = ?", message.DedupeKey).
+			Update("status", models.ReminderLogStatusFailed).Error
+		if err != nil {
+			return err
+		}
+
+		reminderIDs := tx.Model(&models.ReminderLog{}).Select("reminder_id").Where("message_id = ?", message.DedupeKey)
+		return tx.Model(&models.Reminder{}).Where("id IN (?)", reminderIDs).Updates(map[string]interface{}{
+			"status":     models.ReminderStatusDeadLettered,
+			"attempts":   attempts,
+			"last_error": lastError,
+		}).Error
+	})
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
+
+// DeferMessages postpones messages without counting a failed attempt
+func (r *outboxRepository) DeferMessages(ctx context.Context, messages []models.OutboxMessage, until time.Time) error {
+	if len(messages) == 0 {
+		return nil
+	}
+
+	err := conn(ctx, r.db).Model(&models.OutboxMessage{}).Where("id IN ?", messageIDs(messages)).Update("next_attempt_at", until).Error
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
+
+func messageIDs(messages []models.OutboxMessage) []uuid.UUID {
+	ids := make([]uuid.UUID, len(messages))
+	for i, message := range messages {
+		ids[i] = message.ID
+	}
+	return ids
+}
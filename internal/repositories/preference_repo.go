@@ -0,0 +1,75 @@
+package repositories
+
+import (
+	"context"
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/google/uuid"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type PreferenceRepository interface {
+	GetPreferences(ctx context.Context, customerID string) (*models.ReminderPreference, error)
+	UpsertPreferences(ctx context.Context, preference *models.ReminderPreference) error
+	GetDigestCustomers(ctx context.Context, customerIDs []uuid.UUID) (map[uuid.UUID]bool, error)
+}
+
+type preferenceRepository struct {
+	db *gorm.DB
+}
+
+func NewPreferenceRepository(db *gorm.DB) PreferenceRepository {
+	return &preferenceRepository{db}
+}
+
+// GetPreferences returns the customer's preferences, or the defaults when the
+// customer never set any
+func (r *preferenceRepository) GetPreferences(ctx context.Context, customerID string) (*models.ReminderPreference, error) {
+	customer_id, err := uuid.Parse(customerID)
+	if err != nil {
+		return nil, errors.NewBadRequestError("Customer ID must be a UUID")
+	}
+
+	var preference models.ReminderPreference
+	err = conn(ctx, r.db).Where("customer_id = ?", customer_id).First(&preference).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return &models.ReminderPreference{CustomerID: customer_id}, nil
+		}
+		return nil, errors.NewInternalError(err)
+	}
+	return &preference, nil
+}
+
+func (r *preferenceRepository) UpsertPreferences(ctx context.Context, preference *models.ReminderPreference) error {
+	err := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "customer_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"digest_enabled", "updated_at"}),
+	}).Create(preference).Error
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
+
+// GetDigestCustomers reports which of the given customers opted into digest mode
+func (r *preferenceRepository) GetDigestCustomers(ctx context.Context, customerIDs []uuid.UUID) (map[uuid.UUID]bool, error) {
+	digestCustomers := make(map[uuid.UUID]bool)
+	if len(customerIDs) == 0 {
+		return digestCustomers, nil
+	}
+
+	var ids []uuid.UUID
+	err := conn(ctx, r.db).Model(&models.ReminderPreference{}).
+		Where("customer_id IN ? AND digest_enabled = ?", customerIDs, true).
+		Pluck("customer_id", &ids).Error
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+
+	for _, id := range ids {
+		digestCustomers[id] = true
+	}
+	return digestCustomers, nil
+}
@@ -1,17 +1,22 @@
 package repositories
 
 import (
+	"context"
+	"fmt"
 	"strings"
 
 	"github.com/PharmaKart/reminder-svc/internal/models"
 	"github.com/PharmaKart/reminder-svc/pkg/errors"
 	"github.com/PharmaKart/reminder-svc/pkg/utils"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReminderLogRepository interface {
-	CreateReminderLog(reminderLog *models.ReminderLog) error
-	ListReminderLogs(reminderID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error)
+	CreateReminderLog(ctx context.Context, reminderLog *models.ReminderLog) error
+	ListReminderLogs(ctx context.Context, reminderID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error)
+	ListAllReminderLogs(ctx context.Context, customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error)
+	RecordDeliveryEvent(ctx context.Context, event *models.DeliveryEvent) error
 }
 
 type reminderLogRepository struct {
@@ -22,14 +27,29 @@ func NewReminderLogRepository(db *gorm.DB) ReminderLogRepository {
 	return &reminderLogRepository{db}
 }
 
-func (r *reminderLogRepository) CreateReminderLog(reminderLog *models.ReminderLog) error {
-	if err := r.db.Create(reminderLog).Error; err != nil {
+func (r *reminderLogRepository) CreateReminderLog(ctx context.Context, reminderLog *models.ReminderLog) error {
+	if err := conn(ctx, r.db).Create(reminderLog).Error; err != nil {
 		return errors.NewInternalError(err)
 	}
 	return nil
 }
 
-func (r *reminderLogRepository) ListReminderLogs(reminderID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error) {
+func (r *reminderLogRepository) ListReminderLogs(ctx context.Context, reminderID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error) {
+	query := conn(ctx, r.db).Clauses(utils.ReadReplica()).Model(&models.ReminderLog{}).Where("reminder_id = ?", reminderID)
+	return listReminderLogs(query, filter, sortBy, sortOrder, page, limit)
+}
+
+// ListAllReminderLogs lists log entries across reminders. A customerID limits
+// them to that customer's reminders.
+func (r *reminderLogRepository) ListAllReminderLogs(ctx context.Context, customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error) {
+	query := conn(ctx, r.db).Clauses(utils.ReadReplica()).Model(&models.ReminderLog{})
+	if customerID != "" {
+		query = query.Where("reminder_id IN (?)", conn(ctx, r.db).Model(&models.Reminder{}).Select("id").Where("customer_id = ?", customerID))
+	}
+	return listReminderLogs(query, filter, sortBy, sortOrder, page, limit)
+}
+
+func listReminderLogs(query *gorm.DB, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error) {
 	var reminderLogs []models.ReminderLog
 	var total int64
 
@@ -49,8 +69,6 @@ func (r *reminderLogRepository) ListReminderLogs(reminderID string, filter model
 		"notnull": "IS NOT NULL", // IS NOT NULL check
 	}
 
-	query := r.db.Model(&models.ReminderLog{}).Where("reminder_id = ?", reminderID)
-
 	if filter != (models.Filter{}) {
 		if _, allowed := allowedColumns[filter.Column]; !allowed {
 			return nil, 0, errors.NewBadRequestError("invalid filter column: " + filter.Column)
@@ -104,3 +122,46 @@ func (r *reminderLogRepository) ListReminderLogs(reminderID string, filter model
 
 	return reminderLogs, int32(total), nil
 }
+
+// RecordDeliveryEvent stores a delivery callback and moves the log entries of
+// the message, one per reminder in a digest, to the reported status. The
+// entries are locked first, so concurrent callbacks for a message are applied
+// one at a time.
+func (r *reminderLogRepository) RecordDeliveryEvent(ctx context.Context, event *models.DeliveryEvent) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		var reminderLogs []models.ReminderLog
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("message_id = ?", event.MessageID).
+			Order("created_at asc").
+			Find(&reminderLogs).Error
+		if err != nil {
+			return err
+		}
+		if len(reminderLogs) == 0 {
+			return gorm.ErrRecordNotFound
+		}
+
+		event.ReminderLogID = reminderLogs[0].ID
+		if err := tx.Create(event).Error; err != nil {
+			return err
+		}
+
+		for _, reminderLog := range reminderLogs {
+			if !models.ReminderLogStatusAdvances(reminderLog.Status, event.Status) {
+				continue
+			}
+
+			if err := tx.Model(&models.ReminderLog{}).Where("id = ?", reminderLog.ID).Update("status", event.Status).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return errors.NewNotFoundError(fmt.Sprintf("Reminder log for message '%s' not found", event.MessageID))
+		}
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
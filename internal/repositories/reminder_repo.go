@@ -1,6 +1,8 @@
 package repositories
 
 import (
+	"context"
+	"database/sql"
 	"fmt"
 	"strings"
 	"time"
@@ -10,18 +12,28 @@ import (
 	"github.com/PharmaKart/reminder-svc/pkg/utils"
 	"github.com/google/uuid"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
 )
 
 type ReminderRepository interface {
-	GetReminderCustomer(reminderID string) (string, error)
-	ScheduleReminder(reminder *models.Reminder) error
-	GetPendingReminders() ([]ReminderWithCustomer, error)
-	ListReminders(filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error)
-	ListCustomerReminders(customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error)
-	UpdateReminder(reminder *models.Reminder) error
-	DeleteReminder(reminderID string) error
-	ToggleReminder(reminderID string) error
-	ReminderExists(productID, customerID string) (bool, error)
+	GetReminder(ctx context.Context, reminderID string) (*models.Reminder, error)
+	GetReminderWithCustomer(ctx context.Context, reminderID string) (*ReminderWithCustomer, error)
+	ScheduleReminder(ctx context.Context, reminder *models.Reminder, supplyDays int) error
+	GetPendingReminders(ctx context.Context, after *ReminderCursor, limit int) ([]ReminderWithCustomer, error)
+	ListReminders(ctx context.Context, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error)
+	ListCustomerReminders(ctx context.Context, customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error)
+	UpdateReminder(ctx context.Context, reminder *models.Reminder) error
+	DeleteReminder(ctx context.Context, reminderID string) error
+	ToggleReminder(ctx context.Context, reminderID string) error
+	ReminderExists(ctx context.Context, productID, customerID string) (bool, error)
+	GetReminderByProductAndCustomer(ctx context.Context, productID, customerID string) (*models.Reminder, error)
+	AdvanceReminder(ctx context.Context, reminderID uuid.UUID, orderID uuid.UUID, supplyDays int) (*ReminderChange, error)
+	CancelOrderReminders(ctx context.Context, orderID string) ([]ReminderChange, error)
+	GetRetryableReminders(ctx context.Context, after *ReminderCursor, limit int) ([]ReminderWithCustomer, error)
+	GetOldestDueReminderDate(ctx context.Context) (*time.Time, error)
+	RecordReminderFailure(ctx context.Context, reminderID string, attempts int, nextAttemptAt *time.Time, lastError string) error
+	RequeueReminder(ctx context.Context, reminderID string) ([]ReminderChange, error)
+	SkipReminder(ctx context.Context, reminder *models.Reminder, status string) error
 }
 
 type reminderRepository struct {
@@ -32,50 +44,153 @@ func NewReminderRepository(db *gorm.DB) ReminderRepository {
 	return &reminderRepository{db}
 }
 
-func (r *reminderRepository) ScheduleReminder(reminder *models.Reminder) error {
-	if err := r.db.Create(reminder).Error; err != nil {
+// ScheduleReminder creates the reminder and records its order as the one that
+// created it
+func (r *reminderRepository) ScheduleReminder(ctx context.Context, reminder *models.Reminder, supplyDays int) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(reminder).Error; err != nil {
+			return err
+		}
+
+		return tx.Create(&models.ReminderOrder{
+			ReminderID: reminder.ID,
+			OrderID:    reminder.OrderID,
+			SupplyDays: supplyDays,
+		}).Error
+	})
+	if err != nil {
 		return errors.NewInternalError(err)
 	}
 	return nil
 }
 
+// ReminderChange is a reminder before and after a change
+type ReminderChange struct {
+	Before models.Reminder
+	After  models.Reminder
+}
+
 type ReminderWithCustomer struct {
-	Reminder models.Reminder
+	Reminder models.Reminder `gorm:"embedded"`
 	Email    string
 	Phone    *string
 	Product  string
 }
 
-func (r *reminderRepository) GetReminderCustomer(reminderID string) (string, error) {
-	var customerID uuid.UUID
-	err := r.db.Table("reminders").Select("customer_id").Where("id = ?", reminderID).Row().Scan(&customerID)
-	if err != nil {
+// ReminderCursor marks the last reminder of a page of due reminders. Pages are
+// ordered by customer so a customer's reminders stay together.
+type ReminderCursor struct {
+	CustomerID uuid.UUID
+	ID         uuid.UUID
+}
+
+func (r *reminderRepository) GetReminder(ctx context.Context, reminderID string) (*models.Reminder, error) {
+	var reminder models.Reminder
+	if err := conn(ctx, r.db).Where("id = ?", reminderID).First(&reminder).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
-			return "", errors.NewNotFoundError(fmt.Sprintf("Reminder with ID '%s' not found", reminderID))
+			return nil, errors.NewNotFoundError(fmt.Sprintf("Reminder with ID '%s' not found", reminderID))
 		}
-		return "", errors.NewInternalError(err)
+		return nil, errors.NewInternalError(err)
 	}
-	return customerID.String(), nil
+	return &reminder, nil
 }
 
-func (r *reminderRepository) ReminderExists(productID, customerID string) (bool, error) {
+// GetReminderWithCustomer returns a reminder with the customer's current contact
+// details and the product name. They are empty if the customer or product no
+// longer exists.
+func (r *reminderRepository) GetReminderWithCustomer(ctx context.Context, reminderID string) (*ReminderWithCustomer, error) {
+	var results []ReminderWithCustomer
+
+	err := conn(ctx, r.db).
+		Table("reminders").
+		Select("reminders.*, customers.email, customers.phone, products.name as product").
+		Joins("LEFT JOIN customers ON customers.id = reminders.customer_id").
+		Joins("LEFT JOIN products ON products.id = reminders.product_id").
+		Where("reminders.id = ?", reminderID).
+		Limit(1).
+		Scan(&results).Error
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+
+	if len(results) == 0 {
+		return nil, errors.NewNotFoundError(fmt.Sprintf("Reminder with ID '%s' not found", reminderID))
+	}
+	return &results[0], nil
+}
+
+// byProductAndCustomer scopes a query to the reminder for a customer's product
+func byProductAndCustomer(productID, customerID string) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		return db.Where("product_id = ? AND customer_id = ?", productID, customerID)
+	}
+}
+
+func (r *reminderRepository) ReminderExists(ctx context.Context, productID, customerID string) (bool, error) {
 	var count int64
-	err := r.db.Model(&models.Reminder{}).Where("product_id = ? AND customer_id = ?", productID, customerID).Count(&count).Error
+	err := conn(ctx, r.db).Model(&models.Reminder{}).Scopes(byProductAndCustomer(productID, customerID)).Count(&count).Error
 	if err != nil {
 		return false, errors.NewInternalError(err)
 	}
 	return count > 0, nil
 }
 
-func (r *reminderRepository) GetPendingReminders() ([]ReminderWithCustomer, error) {
-	var results []ReminderWithCustomer
+func (r *reminderRepository) GetReminderByProductAndCustomer(ctx context.Context, productID, customerID string) (*models.Reminder, error) {
+	var reminder models.Reminder
+	err := conn(ctx, r.db).Scopes(byProductAndCustomer(productID, customerID)).First(&reminder).Error
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("Reminder for product '%s' not found", productID))
+		}
+		return nil, errors.NewInternalError(err)
+	}
+	return &reminder, nil
+}
 
-	err := r.db.
+// duePage limits due reminders to the page following the cursor
+func duePage(after *ReminderCursor, limit int) func(*gorm.DB) *gorm.DB {
+	return func(db *gorm.DB) *gorm.DB {
+		if after != nil {
+			db = db.Where("(reminders.customer_id, reminders.id) > (?, ?)", after.CustomerID, after.ID)
+		}
+		return db.Order("reminders.customer_id, reminders.id").Limit(limit)
+	}
+}
+
+// dueReminders selects enabled, active reminders that have not been sent for
+// their current reminder date, joined with customer contact details
+func (r *reminderRepository) dueReminders(ctx context.Context) *gorm.DB {
+	return conn(ctx, r.db).
 		Table("reminders").
 		Select("reminders.*, customers.email, customers.phone, products.name as product").
 		Joins("JOIN customers ON customers.id = reminders.customer_id").
 		Joins("JOIN products ON products.id = reminders.product_id").
 		Where("reminder_date <= ? AND enabled = ?", time.Now(), true).
+		Where("reminders.status = ?", models.ReminderStatusActive).
+		Where("(last_sent_at IS NULL OR last_sent_at < reminder_date)")
+}
+
+func (r *reminderRepository) GetPendingReminders(ctx context.Context, after *ReminderCursor, limit int) ([]ReminderWithCustomer, error) {
+	var results []ReminderWithCustomer
+
+	err := r.dueReminders(ctx).
+		Where("(next_attempt_at IS NULL OR next_attempt_at <= ?)", time.Now()).
+		Scopes(duePage(after, limit)).
+		Scan(&results).Error
+
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return results, nil
+}
+
+// GetRetryableReminders returns previously failed reminders whose backoff has elapsed
+func (r *reminderRepository) GetRetryableReminders(ctx context.Context, after *ReminderCursor, limit int) ([]ReminderWithCustomer, error) {
+	var results []ReminderWithCustomer
+
+	err := r.dueReminders(ctx).
+		Where("attempts > 0 AND next_attempt_at <= ?", time.Now()).
+		Scopes(duePage(after, limit)).
 		Scan(&results).Error
 
 	if err != nil {
@@ -84,7 +199,21 @@ func (r *reminderRepository) GetPendingReminders() ([]ReminderWithCustomer, erro
 	return results, nil
 }
 
-func (r *reminderRepository) ListReminders(filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error) {
+// GetOldestDueReminderDate returns the reminder date of the longest-waiting due
+// reminder, or nil when none is due
+func (r *reminderRepository) GetOldestDueReminderDate(ctx context.Context) (*time.Time, error) {
+	var oldest sql.NullTime
+	err := r.dueReminders(ctx).Select("MIN(reminders.reminder_date)").Row().Scan(&oldest)
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	if !oldest.Valid {
+		return nil, nil
+	}
+	return &oldest.Time, nil
+}
+
+func (r *reminderRepository) ListReminders(ctx context.Context, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error) {
 	var reminders []models.Reminder
 	var total int64
 
@@ -104,7 +233,7 @@ func (r *reminderRepository) ListReminders(filter models.Filter, sortBy string,
 		"notnull": "IS NOT NULL", // IS NOT NULL check
 	}
 
-	query := r.db.Model(&models.Reminder{})
+	query := conn(ctx, r.db).Clauses(utils.ReadReplica()).Model(&models.Reminder{})
 
 	if filter != (models.Filter{}) {
 		if _, allowed := allowedColumns[filter.Column]; !allowed {
@@ -160,7 +289,7 @@ func (r *reminderRepository) ListReminders(filter models.Filter, sortBy string,
 	return reminders, int32(total), nil
 }
 
-func (r *reminderRepository) ListCustomerReminders(customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error) {
+func (r *reminderRepository) ListCustomerReminders(ctx context.Context, customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error) {
 	var reminders []models.Reminder
 	var total int64
 
@@ -180,7 +309,7 @@ func (r *reminderRepository) ListCustomerReminders(customerID string, filter mod
 		"notnull": "IS NOT NULL", // IS NOT NULL check
 	}
 
-	query := r.db.Model(&models.Reminder{}).Where("customer_id = ?", customerID)
+	query := conn(ctx, r.db).Clauses(utils.ReadReplica()).Model(&models.Reminder{}).Where("customer_id = ?", customerID)
 
 	if filter != (models.Filter{}) {
 		if _, allowed := allowedColumns[filter.Column]; !allowed {
@@ -236,15 +365,15 @@ func (r *reminderRepository) ListCustomerReminders(customerID string, filter mod
 	return reminders, int32(total), nil
 }
 
-func (r *reminderRepository) UpdateReminder(reminder *models.Reminder) error {
-	if err := r.db.Save(reminder).Error; err != nil {
+func (r *reminderRepository) UpdateReminder(ctx context.Context, reminder *models.Reminder) error {
+	if err := conn(ctx, r.db).Save(reminder).Error; err != nil {
 		return errors.NewInternalError(err)
 	}
 	return nil
 }
 
-func (r *reminderRepository) DeleteReminder(reminderID string) error {
-	result := r.db.Where("id = ?", reminderID).Delete(&models.Reminder{})
+func (r *reminderRepository) DeleteReminder(ctx context.Context, reminderID string) error {
+	result := conn(ctx, r.db).Where("id = ?", reminderID).Delete(&models.Reminder{})
 	if result.Error != nil {
 		return errors.NewInternalError(result.Error)
 	}
@@ -254,9 +383,9 @@ func (r *reminderRepository) DeleteReminder(reminderID string) error {
 	return nil
 }
 
-func (r *reminderRepository) ToggleReminder(reminderID string) error {
+func (r *reminderRepository) ToggleReminder(ctx context.Context, reminderID string) error {
 	var reminder models.Reminder
-	if err := r.db.Where("id = ?", reminderID).First(&reminder).Error; err != nil {
+	if err := conn(ctx, r.db).Where("id = ?", reminderID).First(&reminder).Error; err != nil {
 		if err == gorm.ErrRecordNotFound {
 			return errors.NewNotFoundError(fmt.Sprintf("Reminder with ID '%s' not found", reminderID))
 		}
@@ -264,9 +393,294 @@ func (r *reminderRepository) ToggleReminder(reminderID string) error {
 	}
 
 	reminder.Enabled = !reminder.Enabled
-	if err := r.db.Save(&reminder).Error; err != nil {
+	if err := conn(ctx, r.db).Save(&reminder).Error; err != nil {
 		return errors.NewInternalError(err)
 	}
 
 	return nil
 }
+
+// AdvanceReminder moves a reminder to a new order and pushes its reminder date
+// back by the order's supply days. A reminder that was disabled because all of
+// its orders were cancelled is enabled again. It returns nil if the order has
+// already been applied, so replays are no-ops.
+func (r *reminderRepository) AdvanceReminder(ctx context.Context, reminderID uuid.UUID, orderID uuid.UUID, supplyDays int) (*ReminderChange, error) {
+	var change *ReminderChange
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		change = nil
+
+		var reminder models.Reminder
+		if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", reminderID).First(&reminder).Error; err != nil {
+			return err
+		}
+
+		var orders []models.ReminderOrder
+		if err := tx.Where("reminder_id = ?", reminder.ID).Find(&orders).Error; err != nil {
+			return err
+		}
+
+		cancelled := 0
+		for _, order := range orders {
+			if order.OrderID == orderID {
+				return nil
+			}
+			if order.CancelledAt != nil {
+				cancelled++
+			}
+		}
+
+		// Reminders created before orders were tracked only know their latest
+		// order. Record it so cancelling the new order can go back to it.
+		if len(orders) == 0 {
+			if err := tx.Create(&models.ReminderOrder{ReminderID: reminder.ID, OrderID: reminder.OrderID}).Error; err != nil {
+				return err
+			}
+		}
+
+		err := tx.Create(&models.ReminderOrder{
+			ReminderID: reminder.ID,
+			OrderID:    orderID,
+			SupplyDays: supplyDays,
+		}).Error
+		if err != nil {
+			return err
+		}
+
+		before := reminder
+		reminder.OrderID = orderID
+		reminder.ReminderDate = reminder.ReminderDate.AddDate(0, 0, supplyDays)
+		if len(orders) > 0 && cancelled == len(orders) {
+			reminder.Enabled = true
+		}
+		if err := tx.Save(&reminder).Error; err != nil {
+			return err
+		}
+
+		change = &ReminderChange{Before: before, After: reminder}
+		return nil
+	})
+	if err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("Reminder with ID '%s' not found", reminderID))
+		}
+		return nil, errors.NewInternalError(err)
+	}
+	return change, nil
+}
+
+// CancelOrderReminders takes a cancelled order off every reminder it created or
+// advanced. Its supply days come off the reminder date and the reminder goes
+// back to its latest order still standing, or is disabled if there is none.
+// Reminders created before orders were tracked are disabled if the order is
+// their latest. It returns the reminders it changed.
+func (r *reminderRepository) CancelOrderReminders(ctx context.Context, orderID string) ([]ReminderChange, error) {
+	var changes []ReminderChange
+
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		changes = nil
+		now := time.Now()
+
+		var orders []models.ReminderOrder
+		err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("order_id = ? AND cancelled_at IS NULL", orderID).
+			Find(&orders).Error
+		if err != nil {
+			return err
+		}
+
+		for _, order := range orders {
+			if err := tx.Model(&order).Update("cancelled_at", now).Error; err != nil {
+				return err
+			}
+
+			var reminder models.Reminder
+			err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).Where("id = ?", order.ReminderID).First(&reminder).Error
+			if err == gorm.ErrRecordNotFound {
+				// The reminder has been deleted
+				continue
+			}
+			if err != nil {
+				return err
+			}
+
+			before := reminder
+			reminder.ReminderDate = reminder.ReminderDate.AddDate(0, 0, -order.SupplyDays)
+
+			var latest models.ReminderOrder
+			err = tx.Where("reminder_id = ? AND cancelled_at IS NULL", reminder.ID).Order("created_at desc").First(&latest).Error
+			switch {
+			case err == nil:
+				reminder.OrderID = latest.OrderID
+			case err == gorm.ErrRecordNotFound:
+				reminder.Enabled = false
+			default:
+				return err
+			}
+
+			if err := tx.Save(&reminder).Error; err != nil {
+				return err
+			}
+			changes = append(changes, ReminderChange{Before: before, After: reminder})
+		}
+
+		var untracked []models.Reminder
+		err = tx.Model(&untracked).
+			Clauses(clause.Returning{}).
+			Where("order_id = ? AND enabled = ?", orderID, true).
+			Where("NOT EXISTS (SELECT 1 FROM reminder_orders WHERE reminder_orders.reminder_id = reminders.id)").
+			Update("enabled", false).Error
+		if err != nil {
+			return err
+		}
+
+		for _, reminder := range untracked {
+			// Recorded as cancelled so the next order enables the reminder again
+			err := tx.Create(&models.ReminderOrder{
+				ReminderID:  reminder.ID,
+				OrderID:     reminder.OrderID,
+				CancelledAt: &now,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			before := reminder
+			before.Enabled = true
+			changes = append(changes, ReminderChange{Before: before, After: reminder})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return changes, nil
+}
+
+// RecordReminderFailure stores a failed dispatch attempt. A nil nextAttemptAt
+// dead-letters the reminder.
+func (r *reminderRepository) RecordReminderFailure(ctx context.Context, reminderID string, attempts int, nextAttemptAt *time.Time, lastError string) error {
+	status := models.ReminderStatusActive
+	if nextAttemptAt == nil {
+		status = models.ReminderStatusDeadLettered
+	}
+
+	err := conn(ctx, r.db).Model(&models.Reminder{}).Where("id = ?", reminderID).Updates(map[string]interface{}{
+		"attempts":        attempts,
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+		"status":          status,
+	}).Error
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
+
+// RequeueReminder resets a dead-lettered reminder and its dead-lettered outbox
+// messages so they are sent again. The messages' failed log entries go back to
+// queued, and the other reminders a digest message carries are reset with it.
+func (r *reminderRepository) RequeueReminder(ctx context.Context, reminderID string) ([]ReminderChange, error) {
+	var reminder models.Reminder
+	if err := conn(ctx, r.db).Where("id = ?", reminderID).First(&reminder).Error; err != nil {
+		if err == gorm.ErrRecordNotFound {
+			return nil, errors.NewNotFoundError(fmt.Sprintf("Reminder with ID '%s' not found", reminderID))
+		}
+		return nil, errors.NewInternalError(err)
+	}
+
+	if reminder.Status != models.ReminderStatusDeadLettered {
+		return nil, errors.NewBadRequestError("Reminder is not dead-lettered")
+	}
+
+	var changes []ReminderChange
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		changes = nil
+
+		var messageIDs []string
+		err := tx.Model(&models.OutboxMessage{}).
+			Where("dedupe_key IN (?) AND status = ?", tx.Model(&models.ReminderLog{}).Select("message_id").Where("reminder_id = ?", reminderID), models.OutboxStatusDeadLettered).
+			Pluck("dedupe_key", &messageIDs).Error
+		if err != nil {
+			return err
+		}
+
+		if len(messageIDs) > 0 {
+			err := tx.Model(&models.OutboxMessage{}).Where("dedupe_key IN ?", messageIDs).Updates(map[string]interface{}{
+				"status":          models.OutboxStatusPending,
+				"attempts":        0,
+				"next_attempt_at": nil,
+			}).Error
+			if err != nil {
+				return err
+			}
+
+			// Marked sent again when the relay publishes the message
+			err = tx.Model(&models.ReminderLog{}).
+				Where("message_id IN ? AND status = ?", messageIDs, models.ReminderLogStatusFailed).
+				Update("status", models.ReminderLogStatusQueued).Error
+			if err != nil {
+				return err
+			}
+		}
+
+		var reminders []models.Reminder
+		err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+			Where("status = ?", models.ReminderStatusDeadLettered).
+			Where("id = ? OR id IN (?)", reminderID, tx.Model(&models.ReminderLog{}).Select("reminder_id").Where("message_id IN ?", messageIDs)).
+			Find(&reminders).Error
+		if err != nil {
+			return err
+		}
+
+		for _, reminder := range reminders {
+			before := reminder
+			reminder.Status = models.ReminderStatusActive
+			reminder.Attempts = 0
+			reminder.NextAttemptAt = nil
+			reminder.LastError = ""
+
+			err := tx.Model(&models.Reminder{}).Where("id = ?", reminder.ID).Updates(map[string]interface{}{
+				"status":          reminder.Status,
+				"attempts":        reminder.Attempts,
+				"next_attempt_at": reminder.NextAttemptAt,
+				"last_error":      reminder.LastError,
+			}).Error
+			if err != nil {
+				return err
+			}
+			changes = append(changes, ReminderChange{Before: before, After: reminder})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return changes, nil
+}
+
+// SkipReminder closes the reminder's current cycle without sending it, logging
+// why it was skipped
+func (r *reminderRepository) SkipReminder(ctx context.Context, reminder *models.Reminder, status string) error {
+	err := conn(ctx, r.db).Transaction(func(tx *gorm.DB) error {
+		reminderLog := &models.ReminderLog{
+			ReminderID: reminder.ID,
+			OrderID:    reminder.OrderID,
+			Status:     status,
+		}
+		if err := tx.Create(reminderLog).Error; err != nil {
+			return err
+		}
+
+		return tx.Model(&models.Reminder{}).Where("id = ?", reminder.ID).Updates(map[string]interface{}{
+			"last_sent_at":    time.Now(),
+			"attempts":        0,
+			"next_attempt_at": nil,
+			"last_error":      "",
+		}).Error
+	})
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
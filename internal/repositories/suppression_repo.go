@@ -0,0 +1,138 @@
+package repositories
+
+import (
+	"context"
+	"fmt"
+	"strings"
+
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/PharmaKart/reminder-svc/pkg/redact"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SuppressionRepository interface {
+	AddSuppression(ctx context.Context, suppression *models.Suppression) error
+	RemoveSuppression(ctx context.Context, channel, address string) error
+	IsSuppressed(ctx context.Context, channel, address string) (bool, error)
+	ListSuppressions(ctx context.Context, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Suppression, int32, error)
+}
+
+type suppressionRepository struct {
+	db *gorm.DB
+}
+
+func NewSuppressionRepository(db *gorm.DB) SuppressionRepository {
+	return &suppressionRepository{db}
+}
+
+// AddSuppression suppresses an address. Suppressing an address twice keeps the
+// original entry.
+func (r *suppressionRepository) AddSuppression(ctx context.Context, suppression *models.Suppression) error {
+	err := conn(ctx, r.db).Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "channel"}, {Name: "address"}},
+		DoNothing: true,
+	}).Create(suppression).Error
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+	return nil
+}
+
+func (r *suppressionRepository) RemoveSuppression(ctx context.Context, channel, address string) error {
+	result := conn(ctx, r.db).Where("channel = ? AND address = ?", channel, address).Delete(&models.Suppression{})
+	if result.Error != nil {
+		return errors.NewInternalError(result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return errors.NewNotFoundError(fmt.Sprintf("Suppression for %s address '%s' not found", channel, redact.Address(address)))
+	}
+	return nil
+}
+
+func (r *suppressionRepository) IsSuppressed(ctx context.Context, channel, address string) (bool, error) {
+	var count int64
+	err := conn(ctx, r.db).Model(&models.Suppression{}).Where("channel = ? AND address = ?", channel, address).Count(&count).Error
+	if err != nil {
+		return false, errors.NewInternalError(err)
+	}
+	return count > 0, nil
+}
+
+func (r *suppressionRepository) ListSuppressions(ctx context.Context, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Suppression, int32, error) {
+	var suppressions []models.Suppression
+	var total int64
+
+	allowedColumns := utils.GetModelColumns(&models.Suppression{})
+
+	allowedOperators := map[string]string{
+		"eq":      "=",           // Equal to
+		"neq":     "!=",          // Not equal to
+		"gt":      ">",           // Greater than
+		"gte":     ">=",          // Greater than or equal to
+		"lt":      "<",           // Less than
+		"lte":     "<=",          // Less than or equal to
+		"like":    "LIKE",        // LIKE for pattern matching
+		"ilike":   "ILIKE",       // Case insensitive LIKE (for PostgreSQL)
+		"in":      "IN",          // IN for multiple values
+		"null":    "IS NULL",     // IS NULL check
+		"notnull": "IS NOT NULL", // IS NOT NULL check
+	}
+
+	query := conn(ctx, r.db).Clauses(utils.ReadReplica()).Model(&models.Suppression{})
+
+	if filter != (models.Filter{}) {
+		if _, allowed := allowedColumns[filter.Column]; !allowed {
+			return nil, 0, errors.NewBadRequestError("invalid filter column: " + filter.Column)
+		}
+
+		op, allowed := allowedOperators[filter.Operator]
+		if !allowed {
+			return nil, 0, errors.NewBadRequestError("invalid filter operator: " + filter.Operator)
+		}
+
+		switch filter.Operator {
+		case "like", "ilike":
+			query = query.Where(filter.Column+" "+op+" ?", "%"+filter.Value+"%")
+		case "in":
+			values := strings.Split(filter.Value, ",")
+			query = query.Where(filter.Column+" "+op+" (?)", values)
+		case "null", "notnull":
+			query = query.Where(filter.Column + " " + op)
+		default:
+			query = query.Where(filter.Column+" "+op+" ?", filter.Value)
+		}
+	}
+
+	if sortBy != "" {
+		if _, allowed := allowedColumns[sortBy]; !allowed {
+			return nil, 0, errors.NewBadRequestError("invalid sort column: " + sortBy)
+		}
+
+		sortOrder = strings.ToLower(sortOrder)
+		if sortOrder != "asc" && sortOrder != "desc" {
+			sortOrder = "asc"
+		}
+
+		query = query.Order(sortBy + " " + sortOrder)
+	}
+
+	err := query.Count(&total).Error
+	if err != nil {
+		return nil, 0, errors.NewInternalError(err)
+	}
+
+	if limit > 0 {
+		offset := max(int((page-1)*limit), 0)
+		query = query.Offset(offset).Limit(int(limit))
+	}
+
+	err = query.Find(&suppressions).Error
+	if err != nil {
+		return nil, 0, errors.NewInternalError(err)
+	}
+
+	return suppressions, int32(total), nil
+}
@@ -0,0 +1,44 @@
+package repositories
+
+import (
+	"context"
+
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"gorm.io/gorm"
+)
+
+// Transactor runs several repository calls in one database transaction
+type Transactor interface {
+	// WithTransaction calls fn with a context whose repository calls run in
+	// one transaction. It commits when fn returns nil and rolls back otherwise.
+	WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error
+}
+
+type transactor struct {
+	db *gorm.DB
+}
+
+func NewTransactor(db *gorm.DB) Transactor {
+	return &transactor{db}
+}
+
+type txKey struct{}
+
+func (t *transactor) WithTransaction(ctx context.Context, fn func(ctx context.Context) error) error {
+	err := conn(ctx, t.db).Transaction(func(tx *gorm.DB) error {
+		return fn(context.WithValue(ctx, txKey{}, tx))
+	})
+	if _, ok := err.(*errors.AppError); err != nil && !ok {
+		return errors.NewInternalError(err)
+	}
+	return err
+}
+
+// conn returns the transaction started by WithTransaction for ctx, or db
+// outside of one, bound to ctx
+func conn(ctx context.Context, db *gorm.DB) *gorm.DB {
+	if tx, ok := ctx.Value(txKey{}).(*gorm.DB); ok {
+		return tx.WithContext(ctx)
+	}
+	return db.WithContext(ctx)
+}
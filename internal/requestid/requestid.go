@@ -0,0 +1,17 @@
+package requestid
+
+import "context"
+
+type requestIDKey struct{}
+
+// NewContext returns a copy of ctx carrying the ID of the request being served
+func NewContext(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDKey{}, requestID)
+}
+
+// FromContext returns the request ID stored in ctx, or an empty string outside
+// of a request, e.g. in scheduled jobs
+func FromContext(ctx context.Context) string {
+	requestID, _ := ctx.Value(requestIDKey{}).(string)
+	return requestID
+}
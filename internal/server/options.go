@@ -0,0 +1,59 @@
+package server
+
+import (
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/credentials"
+	"google.golang.org/grpc/credentials/insecure"
+	"google.golang.org/grpc/keepalive"
+)
+
+// LoadTLS loads the server certificate, and the client CA for mTLS, named in
+// cfg. It returns nil when TLS is disabled.
+func LoadTLS(cfg *config.Config) (*CertReloader, error) {
+	if cfg.GRPC_TLS_CERT_FILE == "" {
+		return nil, nil
+	}
+	return NewCertReloader(cfg.GRPC_TLS_CERT_FILE, cfg.GRPC_TLS_KEY_FILE, cfg.GRPC_TLS_CLIENT_CA_FILE)
+}
+
+// ServerOptions returns the transport, keepalive and limit options for the
+// gRPC server. The server uses TLS when certs is not nil.
+func ServerOptions(cfg *config.Config, certs *CertReloader) []grpc.ServerOption {
+	opts := []grpc.ServerOption{
+		grpc.KeepaliveParams(keepalive.ServerParameters{
+			Time:    cfg.GRPC_KEEPALIVE_TIME,
+			Timeout: cfg.GRPC_KEEPALIVE_TIMEOUT,
+		}),
+		// Close connections that ping more often than allowed. Watch streams
+		// can be idle for long stretches, so pings without streams are fine.
+		grpc.KeepaliveEnforcementPolicy(keepalive.EnforcementPolicy{
+			MinTime:             cfg.GRPC_KEEPALIVE_MIN_TIME,
+			PermitWithoutStream: true,
+		}),
+		grpc.MaxConcurrentStreams(uint32(cfg.GRPC_MAX_CONCURRENT_STREAMS)),
+		grpc.MaxRecvMsgSize(cfg.GRPC_MAX_RECV_MSG_SIZE),
+		grpc.MaxSendMsgSize(cfg.GRPC_MAX_SEND_MSG_SIZE),
+	}
+
+	if certs != nil {
+		opts = append(opts, grpc.Creds(credentials.NewTLS(certs.ServerConfig())))
+	}
+	return opts
+}
+
+// DialOptions returns the options for connecting to this process's gRPC
+// server, as the HTTP gateway does
+func DialOptions(cfg *config.Config, certs *CertReloader) []grpc.DialOption {
+	opts := []grpc.DialOption{
+		grpc.WithDefaultCallOptions(
+			grpc.MaxCallRecvMsgSize(cfg.GRPC_MAX_SEND_MSG_SIZE),
+			grpc.MaxCallSendMsgSize(cfg.GRPC_MAX_RECV_MSG_SIZE),
+		),
+	}
+
+	if certs == nil {
+		return append(opts, grpc.WithTransportCredentials(insecure.NewCredentials()))
+	}
+	return append(opts, grpc.WithTransportCredentials(credentials.NewTLS(certs.LoopbackClientConfig())))
+}
@@ -0,0 +1,196 @@
+package server
+
+import (
+	"context"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"errors"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+)
+
+// minTLSVersion is the oldest TLS version the server accepts
+const minTLSVersion = tls.VersionTLS12
+
+// CertReloader holds the server certificate and the CA that signs client
+// certificates, reloading them when the files change so certificates can be
+// rotated without a restart
+type CertReloader struct {
+	certFile string
+	keyFile  string
+	caFile   string
+
+	mu        sync.RWMutex
+	cert      *tls.Certificate
+	clientCAs *x509.CertPool
+	modTime   time.Time
+	// loaded holds the SHA-256 of every server certificate loaded, so
+	// gateway connections made before a rotation are still recognised
+	loaded map[[sha256.Size]byte]bool
+}
+
+// NewCertReloader loads the certificate and key. Client certificates are
+// required and verified against caFile unless it is empty.
+func NewCertReloader(certFile, keyFile, caFile string) (*CertReloader, error) {
+	r := &CertReloader{
+		certFile: certFile,
+		keyFile:  keyFile,
+		caFile:   caFile,
+		loaded:   make(map[[sha256.Size]byte]bool),
+	}
+
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return nil, err
+	}
+	if err := r.load(modTime); err != nil {
+		return nil, err
+	}
+	return r, nil
+}
+
+// Start checks the files for changes every interval until ctx is cancelled. A
+// failed reload keeps the previous certificate.
+func (r *CertReloader) Start(ctx context.Context, interval time.Duration) {
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-ticker.C:
+			if err := r.reload(); err != nil {
+				utils.Error("Failed to reload TLS certificate", map[string]interface{}{
+					"error": err,
+				})
+			}
+		}
+	}
+}
+
+func (r *CertReloader) reload() error {
+	modTime, err := r.latestModTime()
+	if err != nil {
+		return err
+	}
+
+	r.mu.RLock()
+	unchanged := !modTime.After(r.modTime)
+	r.mu.RUnlock()
+	if unchanged {
+		return nil
+	}
+
+	if err := r.load(modTime); err != nil {
+		return err
+	}
+
+	utils.Info("Reloaded TLS certificate", map[string]interface{}{
+		"cert_file": r.certFile,
+	})
+	return nil
+}
+
+func (r *CertReloader) load(modTime time.Time) error {
+	cert, err := tls.LoadX509KeyPair(r.certFile, r.keyFile)
+	if err != nil {
+		return fmt.Errorf("loading GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE: %w", err)
+	}
+
+	var clientCAs *x509.CertPool
+	if r.caFile != "" {
+		pem, err := os.ReadFile(r.caFile)
+		if err != nil {
+			return fmt.Errorf("reading GRPC_TLS_CLIENT_CA_FILE: %w", err)
+		}
+
+		clientCAs = x509.NewCertPool()
+		if !clientCAs.AppendCertsFromPEM(pem) {
+			return errors.New("GRPC_TLS_CLIENT_CA_FILE contains no PEM certificates")
+		}
+	}
+
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.cert = &cert
+	r.clientCAs = clientCAs
+	r.modTime = modTime
+	r.loaded[sha256.Sum256(cert.Certificate[0])] = true
+	return nil
+}
+
+// IsServerCertificate reports whether cert is one of the server certificates
+// this process has loaded. The HTTP gateway presents it as its client
+// certificate, so it identifies no caller.
+func (r *CertReloader) IsServerCertificate(cert *x509.Certificate) bool {
+	r.mu.RLock()
+	defer r.mu.RUnlock()
+	return r.loaded[sha256.Sum256(cert.Raw)]
+}
+
+// latestModTime returns when any of the files last changed
+func (r *CertReloader) latestModTime() (time.Time, error) {
+	var latest time.Time
+	for _, file := range []string{r.certFile, r.keyFile, r.caFile} {
+		if file == "" {
+			continue
+		}
+
+		info, err := os.Stat(file)
+		if err != nil {
+			return time.Time{}, err
+		}
+		if info.ModTime().After(latest) {
+			latest = info.ModTime()
+		}
+	}
+	return latest, nil
+}
+
+// ServerConfig returns a TLS config that always uses the latest certificate
+// and client CA
+func (r *CertReloader) ServerConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: minTLSVersion,
+		GetConfigForClient: func(*tls.ClientHelloInfo) (*tls.Config, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+
+			config := &tls.Config{
+				MinVersion:   minTLSVersion,
+				Certificates: []tls.Certificate{*r.cert},
+				NextProtos:   []string{"h2"},
+			}
+			if r.clientCAs != nil {
+				config.ClientAuth = tls.RequireAndVerifyClientCert
+				config.ClientCAs = r.clientCAs
+			}
+			return config, nil
+		},
+	}
+}
+
+// LoopbackClientConfig returns a TLS config for dialing this process. It
+// presents the server certificate as the client certificate for mTLS, so that
+// certificate must be signed by the client CA and allow client auth. Calls
+// made with it are identified by their bearer token only.
+func (r *CertReloader) LoopbackClientConfig() *tls.Config {
+	return &tls.Config{
+		MinVersion: minTLSVersion,
+		// The server is this process, reached over loopback, and its
+		// certificate is rarely issued for localhost
+		InsecureSkipVerify: true,
+		GetClientCertificate: func(*tls.CertificateRequestInfo) (*tls.Certificate, error) {
+			r.mu.RLock()
+			defer r.mu.RUnlock()
+			return r.cert, nil
+		},
+	}
+}
@@ -0,0 +1,173 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/auth"
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/internal/repositories"
+	"github.com/PharmaKart/reminder-svc/internal/requestid"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/google/uuid"
+)
+
+type AuditService interface {
+	ListAuditEvents(ctx context.Context, reminderID, customerID, actor, from, to string, page, limit int32) ([]models.AuditEvent, int32, error)
+}
+
+type auditService struct {
+	auditRepo repositories.AuditRepository
+}
+
+func NewAuditService(auditRepo repositories.AuditRepository) AuditService {
+	return &auditService{
+		auditRepo: auditRepo,
+	}
+}
+
+func (s *auditService) ListAuditEvents(ctx context.Context, reminderID, customerID, actor, from, to string, page, limit int32) ([]models.AuditEvent, int32, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	filter := repositories.AuditEventFilter{
+		ReminderID: reminderID,
+		CustomerID: customerID,
+		Actor:      actor,
+	}
+
+	if reminderID != "" {
+		if _, err := uuid.Parse(reminderID); err != nil {
+			return nil, 0, errors.NewValidationError("reminder_id", "must be a UUID")
+		}
+	}
+
+	if customerID != "" {
+		if _, err := uuid.Parse(customerID); err != nil {
+			return nil, 0, errors.NewValidationError("customer_id", "must be a UUID")
+		}
+	}
+
+	if from != "" {
+		fromTime, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, 0, errors.NewValidationError("from", "must be an RFC3339 timestamp")
+		}
+		filter.From = &fromTime
+	}
+
+	if to != "" {
+		toTime, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, 0, errors.NewValidationError("to", "must be an RFC3339 timestamp")
+		}
+		filter.To = &toTime
+	}
+
+	return s.auditRepo.ListAuditEvents(ctx, filter, page, limit)
+}
+
+// auditEntry describes an audited action. Before and After hold the fields of
+// the subject before and after the action; only the fields that changed are
+// stored.
+type auditEntry struct {
+	Action     string
+	ReminderID *uuid.UUID
+	CustomerID *uuid.UUID
+	Before     map[string]interface{}
+	After      map[string]interface{}
+}
+
+// recordAudit appends entry to the audit trail on behalf of the caller in ctx.
+// Call it in the transaction that makes the change it records, so the change
+// is rolled back if the audit event cannot be written.
+func recordAudit(ctx context.Context, auditRepo repositories.AuditRepository, entry auditEntry) error {
+	requestID := requestid.FromContext(ctx)
+
+	principal := auth.FromContext(ctx)
+	actor := principal.ID
+	if actor == "" {
+		// Scheduled jobs and order events run outside of any request
+		actor = models.AuditActorSystem
+		if requestID != "" {
+			actor = principal.String()
+		}
+	}
+
+	before, after := auditDiff(entry.Before, entry.After)
+
+	return auditRepo.RecordAuditEvent(ctx, &models.AuditEvent{
+		ReminderID: entry.ReminderID,
+		CustomerID: entry.CustomerID,
+		Actor:      actor,
+		ActorRole:  principal.Role,
+		Action:     entry.Action,
+		Before:     auditJSON(before),
+		After:      auditJSON(after),
+		RequestID:  requestID,
+	})
+}
+
+// auditDiff drops the fields that are the same before and after
+func auditDiff(before, after map[string]interface{}) (map[string]interface{}, map[string]interface{}) {
+	if before == nil || after == nil {
+		return before, after
+	}
+
+	changedBefore := make(map[string]interface{})
+	changedAfter := make(map[string]interface{})
+	for key, value := range after {
+		if previous, ok := before[key]; !ok || previous != value {
+			changedBefore[key] = before[key]
+			changedAfter[key] = value
+		}
+	}
+	return changedBefore, changedAfter
+}
+
+func auditJSON(fields map[string]interface{}) string {
+	if fields == nil {
+		return "{}"
+	}
+
+	data, err := json.Marshal(fields)
+	if err != nil {
+		return "{}"
+	}
+	return string(data)
+}
+
+// reminderAuditFields are the fields of a reminder recorded in the audit trail
+func reminderAuditFields(reminder *models.Reminder) map[string]interface{} {
+	return map[string]interface{}{
+		"customer_id":   reminder.CustomerID.String(),
+		"order_id":      reminder.OrderID.String(),
+		"product_id":    reminder.ProductID.String(),
+		"reminder_date": reminder.ReminderDate.Format(time.RFC3339),
+		"enabled":       reminder.Enabled,
+		"status":        reminder.Status,
+	}
+}
+
+// reminderAudit describes an action on a reminder. A nil before records a
+// created reminder and a nil after a deleted one.
+func reminderAudit(action string, before, after *models.Reminder) auditEntry {
+	entry := auditEntry{Action: action}
+
+	subject := after
+	if subject == nil {
+		subject = before
+	}
+	entry.ReminderID = &subject.ID
+	entry.CustomerID = &subject.CustomerID
+
+	if before != nil {
+		entry.Before = reminderAuditFields(before)
+	}
+	if after != nil {
+		entry.After = reminderAuditFields(after)
+	}
+	return entry
+}
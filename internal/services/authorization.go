@@ -0,0 +1,47 @@
+package services
+
+import (
+	"context"
+
+	"github.com/PharmaKart/reminder-svc/internal/auth"
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+)
+
+// authorizeReminder lets admins act on any reminder and everyone else only on
+// their own
+func authorizeReminder(ctx context.Context, reminder *models.Reminder) error {
+	return authorizeCustomer(ctx, reminder.CustomerID.String())
+}
+
+// authorizeCustomer lets admins act for any customer and everyone else only
+// for themselves
+func authorizeCustomer(ctx context.Context, customerID string) error {
+	principal := auth.FromContext(ctx)
+	if principal.IsAdmin() {
+		return nil
+	}
+
+	if principal.ID == "" || principal.ID != customerID {
+		return errors.NewAuthError("Access denied")
+	}
+	return nil
+}
+
+// requireAdmin rejects callers without the admin role
+func requireAdmin(ctx context.Context) error {
+	if !auth.FromContext(ctx).IsAdmin() {
+		return errors.NewAuthError("Admin access required")
+	}
+	return nil
+}
+
+// requireService rejects callers other than services, identified by mTLS or a
+// service token, and admins
+func requireService(ctx context.Context) error {
+	principal := auth.FromContext(ctx)
+	if !principal.IsService() && !principal.IsAdmin() {
+		return errors.NewAuthError("Service access required")
+	}
+	return nil
+}
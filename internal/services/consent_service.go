@@ -0,0 +1,148 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/auth"
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/internal/repositories"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/google/uuid"
+)
+
+type ConsentService interface {
+	RecordConsent(ctx context.Context, customerID string, channel string, granted bool, source string, textVersion string, recordedAt string) error
+	GetConsentHistory(ctx context.Context, customerID string, channel string) ([]models.Consent, error)
+	ExportConsents(ctx context.Context, customerID string, from string, to string, page, limit int32) ([]models.Consent, int32, error)
+	Unsubscribe(ctx context.Context, token string) error
+}
+
+type consentService struct {
+	consentRepo       repositories.ConsentRepository
+	auditRepo         repositories.AuditRepository
+	transactor        repositories.Transactor
+	unsubscribeSecret string
+}
+
+func NewConsentService(consentRepo repositories.ConsentRepository, auditRepo repositories.AuditRepository, transactor repositories.Transactor, unsubscribeSecret string) ConsentService {
+	return &consentService{
+		consentRepo:       consentRepo,
+		auditRepo:         auditRepo,
+		transactor:        transactor,
+		unsubscribeSecret: unsubscribeSecret,
+	}
+}
+
+// RecordConsent records a customer granting or withdrawing consent. Services
+// and admins can record it for any customer, customers only for themselves.
+func (s *consentService) RecordConsent(ctx context.Context, customerID string, channel string, granted bool, source string, textVersion string, recordedAt string) error {
+	if !auth.FromContext(ctx).IsService() {
+		if err := authorizeCustomer(ctx, customerID); err != nil {
+			return err
+		}
+	}
+	return s.recordConsent(ctx, customerID, channel, granted, source, textVersion, recordedAt)
+}
+
+// recordConsent validates and records consent, audited in the same transaction
+func (s *consentService) recordConsent(ctx context.Context, customerID string, channel string, granted bool, source string, textVersion string, recordedAt string) error {
+	customer_id, err := uuid.Parse(customerID)
+	if err != nil {
+		return errors.NewValidationError("customer_id", "must be a UUID")
+	}
+
+	if !models.IsChannel(channel) {
+		return errors.NewValidationError("channel", "must be email or sms")
+	}
+
+	if source == "" {
+		return errors.NewValidationError("source", "is required")
+	}
+
+	// CASL requires the wording the customer agreed to
+	if granted && textVersion == "" {
+		return errors.NewValidationError("text_version", "is required when granting consent")
+	}
+
+	recorded_at := time.Now()
+	if recordedAt != "" {
+		recorded_at, err = time.Parse(time.RFC3339, recordedAt)
+		if err != nil {
+			return errors.NewValidationError("recorded_at", "must be an RFC3339 timestamp")
+		}
+	}
+
+	consent := &models.Consent{
+		CustomerID:  customer_id,
+		Channel:     channel,
+		Granted:     granted,
+		Source:      source,
+		TextVersion: textVersion,
+		RecordedAt:  recorded_at,
+	}
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.consentRepo.RecordConsent(ctx, consent); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.auditRepo, auditEntry{
+			Action:     models.AuditActionConsentRecorded,
+			CustomerID: &consent.CustomerID,
+			After: map[string]interface{}{
+				"channel":      consent.Channel,
+				"granted":      consent.Granted,
+				"source":       consent.Source,
+				"text_version": consent.TextVersion,
+				"recorded_at":  consent.RecordedAt.Format(time.RFC3339),
+			},
+		})
+	})
+}
+
+func (s *consentService) GetConsentHistory(ctx context.Context, customerID string, channel string) ([]models.Consent, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, err
+	}
+
+	if _, err := uuid.Parse(customerID); err != nil {
+		return nil, errors.NewInternalError(err)
+	}
+	return s.consentRepo.GetConsentHistory(ctx, customerID, channel)
+}
+
+func (s *consentService) ExportConsents(ctx context.Context, customerID string, from string, to string, page, limit int32) ([]models.Consent, int32, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	var fromTime, toTime *time.Time
+
+	if from != "" {
+		t, err := time.Parse(time.RFC3339, from)
+		if err != nil {
+			return nil, 0, errors.NewValidationError("from", "must be an RFC3339 timestamp")
+		}
+		fromTime = &t
+	}
+
+	if to != "" {
+		t, err := time.Parse(time.RFC3339, to)
+		if err != nil {
+			return nil, 0, errors.NewValidationError("to", "must be an RFC3339 timestamp")
+		}
+		toTime = &t
+	}
+
+	return s.consentRepo.ExportConsents(ctx, customerID, fromTime, toTime, page, limit)
+}
+
+// Unsubscribe withdraws consent for the customer and channel an unsubscribe
+// token was issued for. The signed token stands in for the caller check.
+func (s *consentService) Unsubscribe(ctx context.Context, token string) error {
+	customerID, channel, err := parseUnsubscribeToken(s.unsubscribeSecret, token)
+	if err != nil {
+		return err
+	}
+
+	return s.recordConsent(ctx, customerID, channel, false, models.ConsentSourceUnsubscribeLink, "", "")
+}
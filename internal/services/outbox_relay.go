@@ -0,0 +1,194 @@
+package services
+
+import (
+	"context"
+	"strings"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/dispatcher"
+	"github.com/PharmaKart/reminder-svc/internal/encryption"
+	"github.com/PharmaKart/reminder-svc/internal/eventbus"
+	"github.com/PharmaKart/reminder-svc/internal/metrics"
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/internal/repositories"
+	"github.com/PharmaKart/reminder-svc/internal/tracing"
+	"github.com/PharmaKart/reminder-svc/pkg/redact"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const outboxBatchSize = 100
+
+// outboxClaimLease is how long a relay run holds the messages it claims. It is
+// well above the time to send a batch; a run that dies mid-batch leaves its
+// messages to be claimed again once the lease expires.
+const outboxClaimLease = 5 * time.Minute
+
+// OutboxRelay publishes pending outbox messages. A message is only marked sent
+// after the dispatcher accepts it, so delivery is at-least-once and consumers
+// dedupe on the message's dedupe key. Failed sends are retried with backoff
+// until the retry policy dead-letters them.
+type OutboxRelay interface {
+	RelayPendingMessages(ctx context.Context)
+}
+
+type outboxRelay struct {
+	outboxRepo  repositories.OutboxRepository
+	dispatcher  dispatcher.Dispatcher
+	retryPolicy RetryPolicy
+	cipher      *encryption.Cipher
+	bus         eventbus.Bus
+}
+
+func NewOutboxRelay(outboxRepo repositories.OutboxRepository, dispatcher dispatcher.Dispatcher, retryPolicy RetryPolicy, cipher *encryption.Cipher, bus eventbus.Bus) OutboxRelay {
+	return &outboxRelay{
+		outboxRepo:  outboxRepo,
+		dispatcher:  dispatcher,
+		retryPolicy: retryPolicy,
+		cipher:      cipher,
+		bus:         bus,
+	}
+}
+
+func (r *outboxRelay) RelayPendingMessages(ctx context.Context) {
+	messages, err := r.outboxRepo.ClaimPendingMessages(ctx, outboxBatchSize, outboxClaimLease)
+	if err != nil {
+		utils.ErrorContext(ctx, "Failed to claim pending outbox messages", map[string]interface{}{
+			"error": err,
+		})
+		return
+	}
+
+	for i := range messages {
+		// Unsent messages are claimed again once their lease expires
+		if ctx.Err() != nil {
+			return
+		}
+
+		if limitErr := r.relay(ctx, &messages[i]); limitErr != nil {
+			// Every remaining message would hit the same global limit
+			utils.WarnContext(ctx, "Global rate limit reached, stopping relay run", map[string]interface{}{
+				"retry_after": limitErr.RetryAfter.String(),
+			})
+			r.deferMessages(ctx, messages[i:], limitErr.RetryAfter)
+			return
+		}
+	}
+}
+
+// relay publishes one message in the trace of the dispatch run that queued it.
+// It returns the rate limit error when the global limit is reached and the run
+// should stop.
+func (r *outboxRelay) relay(ctx context.Context, message *models.OutboxMessage) *dispatcher.RateLimitError {
+	ctx, span := tracing.Start(tracing.Extract(ctx, message.TraceParent), "OutboxRelay.send",
+		attribute.String("message_id", message.DedupeKey),
+		attribute.Int("message.attempts", message.Attempts),
+	)
+	defer span.End()
+
+	// The payload is only decrypted for the dispatcher, the stored message keeps
+	// the ciphertext
+	payload, err := r.cipher.Decrypt(ctx, message.Payload)
+	if err != nil {
+		tracing.RecordError(span, err)
+		r.recordFailure(ctx, message, err)
+		return nil
+	}
+	outgoing := *message
+	outgoing.Payload = payload
+
+	if err := r.dispatcher.Send(ctx, &outgoing); err != nil {
+		tracing.RecordError(span, err)
+
+		if limitErr, ok := dispatcher.IsRateLimitError(err); ok {
+			if limitErr.Scope == dispatcher.RateLimitScopeGlobal {
+				return limitErr
+			}
+
+			utils.InfoContext(ctx, "Outbox message throttled", map[string]interface{}{
+				"message_id":  message.ID.String(),
+				"scope":       limitErr.Scope,
+				"retry_after": limitErr.RetryAfter.String(),
+			})
+			r.deferMessages(ctx, []models.OutboxMessage{*message}, limitErr.RetryAfter)
+			return nil
+		}
+
+		r.recordFailure(ctx, message, err)
+		return nil
+	}
+
+	countMessage(metrics.OutcomeSent, message)
+
+	if err := r.outboxRepo.MarkSent(ctx, message); err != nil {
+		// The message will be published again once its claim expires
+		utils.ErrorContext(ctx, "Failed to mark outbox message sent", map[string]interface{}{
+			"error":      err,
+			"message_id": message.ID.String(),
+		})
+		return nil
+	}
+
+	r.bus.Publish(ctx, messageEvent(eventbus.ReminderSent, message, ""))
+	return nil
+}
+
+// deferMessages hands claimed messages back to be sent after retryAfter
+func (r *outboxRelay) deferMessages(ctx context.Context, messages []models.OutboxMessage, retryAfter time.Duration) {
+	if err := r.outboxRepo.DeferMessages(ctx, messages, time.Now().Add(retryAfter)); err != nil {
+		utils.ErrorContext(ctx, "Failed to defer outbox messages", map[string]interface{}{
+			"error":    err,
+			"messages": len(messages),
+		})
+	}
+}
+
+func (r *outboxRelay) recordFailure(ctx context.Context, message *models.OutboxMessage, sendErr error) {
+	var nextAttemptAt *time.Time
+	if next, ok := r.retryPolicy.NextAttempt(message.Attempts + 1); ok {
+		nextAttemptAt = &next
+	}
+
+	countMessage(metrics.OutcomeFailed, message)
+
+	utils.ErrorContext(ctx, "Failed to send outbox message", map[string]interface{}{
+		"error":         sendErr,
+		"message_id":    message.ID.String(),
+		"attempts":      message.Attempts + 1,
+		"dead_lettered": nextAttemptAt == nil,
+	})
+
+	lastError := redact.Text(sendErr.Error())
+	if err := r.outboxRepo.MarkFailed(ctx, message, nextAttemptAt, lastError); err != nil {
+		utils.ErrorContext(ctx, "Failed to record outbox failure", map[string]interface{}{
+			"error":      err,
+			"message_id": message.ID.String(),
+		})
+	}
+
+	r.bus.Publish(ctx, messageEvent(eventbus.ReminderFailed, message, lastError))
+}
+
+// messageEvent describes what happened to an outgoing message. Digests carry
+// their digest ID rather than a reminder ID.
+func messageEvent(eventType eventbus.EventType, message *models.OutboxMessage, status string) eventbus.Event {
+	event := eventbus.Event{
+		Type:       eventType,
+		CustomerID: message.CustomerID.String(),
+		MessageID:  message.DedupeKey,
+		Status:     status,
+	}
+	if message.ReminderID != nil {
+		event.ReminderID = message.ReminderID.String()
+	}
+	if message.DigestID != nil {
+		event.DigestID = message.DigestID.String()
+	}
+	return event
+}
+
+func countMessage(outcome string, message *models.OutboxMessage) {
+	for _, channel := range strings.Split(message.Channels, ",") {
+		metrics.RemindersTotal.WithLabelValues(outcome, channel).Inc()
+	}
+}
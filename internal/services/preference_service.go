@@ -0,0 +1,54 @@
+package services
+
+import (
+	"context"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/internal/repositories"
+)
+
+type PreferenceService interface {
+	GetPreferences(ctx context.Context, customerID string) (*models.ReminderPreference, error)
+	UpdatePreferences(ctx context.Context, customerID string, digestEnabled bool) (*models.ReminderPreference, error)
+}
+
+type preferenceService struct {
+	preferenceRepo repositories.PreferenceRepository
+}
+
+func NewPreferenceService(preferenceRepo repositories.PreferenceRepository) PreferenceService {
+	return &preferenceService{
+		preferenceRepo: preferenceRepo,
+	}
+}
+
+// GetPreferences returns the customer's preferences. Admins can read any
+// customer's, everyone else only their own.
+func (s *preferenceService) GetPreferences(ctx context.Context, customerID string) (*models.ReminderPreference, error) {
+	if err := authorizeCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+	return s.preferenceRepo.GetPreferences(ctx, customerID)
+}
+
+// UpdatePreferences sets the customer's preferences. Admins can change any
+// customer's, everyone else only their own.
+func (s *preferenceService) UpdatePreferences(ctx context.Context, customerID string, digestEnabled bool) (*models.ReminderPreference, error) {
+	if err := authorizeCustomer(ctx, customerID); err != nil {
+		return nil, err
+	}
+
+	preference, err := s.preferenceRepo.GetPreferences(ctx, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	preference.DigestEnabled = digestEnabled
+	preference.UpdatedAt = time.Now()
+
+	if err := s.preferenceRepo.UpsertPreferences(ctx, preference); err != nil {
+		return nil, err
+	}
+	return preference, nil
+}
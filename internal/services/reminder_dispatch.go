@@ -0,0 +1,607 @@
+package services
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/internal/eventbus"
+	"github.com/PharmaKart/reminder-svc/internal/metrics"
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/internal/repositories"
+	"github.com/PharmaKart/reminder-svc/internal/tracing"
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+	"github.com/PharmaKart/reminder-svc/pkg/redact"
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"github.com/google/uuid"
+	"go.opentelemetry.io/otel/attribute"
+)
+
+const (
+	MessageTypeReminder = "reminder"
+	MessageTypeDigest   = "digest"
+)
+
+// Recipient holds the contact details shared by every outgoing message
+type Recipient struct {
+	CustomerID string `json:"customer_id"`
+	Email      string `json:"email"`
+	Phone      string `json:"phone"`
+	// Signed tokens for the unsubscribe link of each channel in the message
+	UnsubscribeTokens map[string]string `json:"unsubscribe_tokens,omitempty"`
+}
+
+// Channels returns the channels the recipient has an address for
+func (r *Recipient) Channels() []string {
+	var channels []string
+	if r.Email != "" {
+		channels = append(channels, models.ChannelEmail)
+	}
+	if r.Phone != "" {
+		channels = append(channels, models.ChannelSMS)
+	}
+	return channels
+}
+
+type ReminderMessage struct {
+	Type       string `json:"type"`
+	MessageID  string `json:"message_id"`
+	ReminderID string `json:"reminder_id"`
+	Recipient
+	OrderID      string `json:"order_id"`
+	ProductID    string `json:"product_id"`
+	ReminderDate string `json:"reminder_date"`
+	TraceID      string `json:"trace_id,omitempty"`
+}
+
+// DigestMessage bundles every due reminder of a customer into one message
+type DigestMessage struct {
+	Type      string `json:"type"`
+	MessageID string `json:"message_id"`
+	DigestID  string `json:"digest_id"`
+	Recipient
+	Items   []DigestItem `json:"items"`
+	TraceID string       `json:"trace_id,omitempty"`
+}
+
+type DigestItem struct {
+	ReminderID   string `json:"reminder_id"`
+	OrderID      string `json:"order_id"`
+	ProductID    string `json:"product_id"`
+	Product      string `json:"product"`
+	ReminderDate string `json:"reminder_date"`
+}
+
+// DispatchRun summarises one run of a dispatch job
+type DispatchRun struct {
+	Kind          string
+	StartedAt     time.Time
+	FinishedAt    time.Time // Zero while the run is in progress
+	Batches       int
+	FailedBatches int
+}
+
+func (s *reminderService) StartReminderService(ctx context.Context, cfg *config.Config) {
+	s.dispatchDueReminders(ctx, cfg, "pending", s.GetPendingReminders)
+}
+
+// RetryFailedReminders dispatches reminders whose previous attempt failed and
+// whose backoff has elapsed
+func (s *reminderService) RetryFailedReminders(ctx context.Context, cfg *config.Config) {
+	s.dispatchDueReminders(ctx, cfg, "retryable", s.reminderRepo.GetRetryableReminders)
+}
+
+// dueReminderPager fetches the page of due reminders following the cursor
+type dueReminderPager func(ctx context.Context, after *repositories.ReminderCursor, limit int) ([]repositories.ReminderWithCustomer, error)
+
+// dispatchDueReminders pages through due reminders and hands each batch to a
+// pool of workers. A failed batch is recorded and the run carries on with the
+// next one.
+func (s *reminderService) dispatchDueReminders(ctx context.Context, cfg *config.Config, kind string, fetch dueReminderPager) {
+	ctx, span := tracing.Start(ctx, "ReminderService.dispatchDueReminders", attribute.String("dispatch.kind", kind))
+	defer span.End()
+
+	batchSize := cfg.DISPATCH_BATCH_SIZE
+	if batchSize <= 0 {
+		batchSize = 500
+	}
+
+	concurrency := cfg.DISPATCH_CONCURRENCY
+	if concurrency <= 0 {
+		concurrency = 1
+	}
+
+	run := DispatchRun{Kind: kind, StartedAt: time.Now()}
+	s.recordRun(run)
+	s.updateQueueLag(ctx)
+
+	var batchCount, failedBatches atomic.Int32
+
+	batches := make(chan []repositories.ReminderWithCustomer)
+	var wg sync.WaitGroup
+	for i := 0; i < concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for batch := range batches {
+				batchCount.Add(1)
+				if !s.dispatchBatch(ctx, cfg, batch) {
+					failedBatches.Add(1)
+				}
+			}
+		}()
+	}
+
+	var cursor *repositories.ReminderCursor
+	var carry []repositories.ReminderWithCustomer
+
+	for ctx.Err() == nil {
+		page, err := fetch(ctx, cursor, batchSize)
+		if err != nil {
+			utils.ErrorContext(ctx, fmt.Sprintf("Failed to get %s reminders", kind), map[string]interface{}{
+				"error": err,
+			})
+			break
+		}
+
+		full := len(page) == batchSize
+		if len(page) > 0 {
+			last := page[len(page)-1].Reminder
+			cursor = &repositories.ReminderCursor{CustomerID: last.CustomerID, ID: last.ID}
+		}
+
+		batch := append(carry, page...)
+		carry = nil
+
+		// Hold back the last customer's reminders until the next page, so a
+		// digest is never split across batches
+		if full {
+			split := len(batch)
+			lastCustomer := batch[split-1].Reminder.CustomerID
+			for split > 0 && batch[split-1].Reminder.CustomerID == lastCustomer {
+				split--
+			}
+			if split > 0 {
+				carry = append(carry, batch[split:]...)
+				batch = batch[:split]
+			}
+		}
+
+		if len(batch) > 0 {
+			batches <- batch
+		}
+
+		if !full {
+			break
+		}
+	}
+
+	if len(carry) > 0 && ctx.Err() == nil {
+		batches <- carry
+	}
+
+	close(batches)
+	wg.Wait()
+
+	run.FinishedAt = time.Now()
+	run.Batches = int(batchCount.Load())
+	run.FailedBatches = int(failedBatches.Load())
+	s.recordRun(run)
+
+	metrics.DispatchDuration.WithLabelValues(kind).Observe(run.FinishedAt.Sub(run.StartedAt).Seconds())
+	s.updateQueueLag(ctx)
+}
+
+// updateQueueLag records how long the oldest due reminder has been waiting
+func (s *reminderService) updateQueueLag(ctx context.Context) {
+	oldest, err := s.reminderRepo.GetOldestDueReminderDate(ctx)
+	if err != nil {
+		utils.ErrorContext(ctx, "Failed to get oldest due reminder", map[string]interface{}{
+			"error": err,
+		})
+		return
+	}
+
+	if oldest == nil {
+		metrics.QueueLag.Set(0)
+		return
+	}
+	metrics.QueueLag.Set(time.Since(*oldest).Seconds())
+}
+
+// contactChannels returns the channels a reminder's customer has an address for
+func contactChannels(reminder repositories.ReminderWithCustomer) []string {
+	recipient := Recipient{Email: reminder.Email}
+	if reminder.Phone != nil {
+		recipient.Phone = *reminder.Phone
+	}
+	return recipient.Channels()
+}
+
+func countReminders(outcome string, reminders []repositories.ReminderWithCustomer) {
+	for _, reminder := range reminders {
+		for _, channel := range contactChannels(reminder) {
+			metrics.RemindersTotal.WithLabelValues(outcome, channel).Inc()
+		}
+	}
+}
+
+func (s *reminderService) recordRun(run DispatchRun) {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+	s.runs[run.Kind] = run
+}
+
+// DispatchRuns returns the latest run of each dispatch job
+func (s *reminderService) DispatchRuns(ctx context.Context) []DispatchRun {
+	s.runsMu.Lock()
+	defer s.runsMu.Unlock()
+
+	runs := make([]DispatchRun, 0, len(s.runs))
+	for _, run := range s.runs {
+		runs = append(runs, run)
+	}
+	sort.Slice(runs, func(i, j int) bool {
+		return runs[i].Kind < runs[j].Kind
+	})
+	return runs
+}
+
+// dispatchBatch builds one message per reminder, or one digest per customer who
+// opted into digest mode, and enqueues the whole batch in one transaction. It
+// reports false when the batch could not be enqueued.
+func (s *reminderService) dispatchBatch(ctx context.Context, cfg *config.Config, reminders []repositories.ReminderWithCustomer) bool {
+	ctx, span := tracing.Start(ctx, "ReminderService.dispatchBatch", attribute.Int("dispatch.batch_size", len(reminders)))
+	defer span.End()
+
+	countReminders(metrics.OutcomeDue, reminders)
+
+	customerIDs := make([]uuid.UUID, 0, len(reminders))
+	for _, reminder := range reminders {
+		customerIDs = append(customerIDs, reminder.Reminder.CustomerID)
+	}
+
+	digestCustomers, err := s.preferenceRepo.GetDigestCustomers(ctx, customerIDs)
+	if err != nil {
+		// Sending individual reminders is better than sending none
+		utils.ErrorContext(ctx, "Failed to get digest preferences", map[string]interface{}{
+			"error": err,
+		})
+		digestCustomers = nil
+	}
+
+	digests := make(map[uuid.UUID][]repositories.ReminderWithCustomer)
+	var digestOrder []uuid.UUID
+	var entries []repositories.OutboxEntry
+
+	for _, reminder := range reminders {
+		customerID := reminder.Reminder.CustomerID
+		if !digestCustomers[customerID] {
+			if entry := s.prepare(ctx, cfg, []repositories.ReminderWithCustomer{reminder}); entry != nil {
+				entries = append(entries, *entry)
+			}
+			continue
+		}
+
+		if _, ok := digests[customerID]; !ok {
+			digestOrder = append(digestOrder, customerID)
+		}
+		digests[customerID] = append(digests[customerID], reminder)
+	}
+
+	for _, customerID := range digestOrder {
+		if entry := s.prepare(ctx, cfg, digests[customerID]); entry != nil {
+			entries = append(entries, *entry)
+		}
+	}
+
+	if len(entries) == 0 {
+		return true
+	}
+
+	enqueued, err := s.outboxRepo.EnqueueBatch(ctx, entries)
+	if err != nil {
+		tracing.RecordError(span, err)
+		utils.ErrorContext(ctx, "Failed to enqueue reminder batch", map[string]interface{}{
+			"error":    err,
+			"messages": len(entries),
+		})
+		for _, entry := range entries {
+			for _, reminder := range entry.Reminders {
+				s.recordFailure(ctx, reminder, err)
+			}
+		}
+		return false
+	}
+
+	for _, entry := range entries {
+		for _, channel := range strings.Split(entry.Message.Channels, ",") {
+			metrics.RemindersTotal.WithLabelValues(metrics.OutcomeQueued, channel).Add(float64(len(entry.Reminders)))
+		}
+		for _, reminder := range entry.Reminders {
+			s.publishLog(ctx, reminder, entry.Message.DedupeKey, models.ReminderLogStatusQueued)
+		}
+	}
+
+	utils.InfoContext(ctx, "Reminder batch queued", map[string]interface{}{
+		"reminders": len(reminders),
+		"messages":  len(entries),
+		"enqueued":  enqueued,
+	})
+	return true
+}
+
+// prepare builds the outgoing message covering the given reminders of a single
+// customer. More than one reminder makes a digest. It returns nil when the
+// reminders were skipped or failed.
+func (s *reminderService) prepare(ctx context.Context, cfg *config.Config, reminders []repositories.ReminderWithCustomer) *repositories.OutboxEntry {
+	first := reminders[0]
+
+	// One span per outgoing message. Its trace travels with the message so
+	// downstream senders can join it.
+	ctx, span := tracing.Start(ctx, "ReminderService.prepareMessage",
+		attribute.String("customer_id", first.Reminder.CustomerID.String()),
+		attribute.Int("message.reminders", len(reminders)),
+	)
+	defer span.End()
+
+	recipient := Recipient{
+		CustomerID: first.Reminder.CustomerID.String(),
+		Email:      first.Email,
+		Phone:      "",
+	}
+
+	// Include phone number if available
+	if first.Phone != nil {
+		recipient.Phone = *first.Phone
+	}
+
+	// Drop suppressed addresses and skip the reminders if none are left
+	if err := s.dropSuppressedContacts(ctx, &recipient); err != nil {
+		utils.ErrorContext(ctx, "Failed to check suppression list", map[string]interface{}{
+			"error":       err,
+			"customer_id": recipient.CustomerID,
+		})
+		s.recordFailures(ctx, reminders, err)
+		return nil
+	}
+
+	if len(recipient.Channels()) == 0 {
+		s.skip(ctx, reminders, models.ReminderLogStatusSuppressed)
+		return nil
+	}
+
+	// Only message channels the customer has consented to
+	if cfg.REQUIRE_CONSENT {
+		if err := s.dropUnconsentedContacts(ctx, &recipient); err != nil {
+			utils.ErrorContext(ctx, "Failed to check consent", map[string]interface{}{
+				"error":       err,
+				"customer_id": recipient.CustomerID,
+			})
+			s.recordFailures(ctx, reminders, err)
+			return nil
+		}
+
+		if len(recipient.Channels()) == 0 {
+			s.skip(ctx, reminders, models.ReminderLogStatusNoConsent)
+			return nil
+		}
+	}
+
+	recipient.UnsubscribeTokens = map[string]string{}
+	for _, channel := range recipient.Channels() {
+		recipient.UnsubscribeTokens[channel] = newUnsubscribeToken(cfg.UNSUBSCRIBE_SECRET, recipient.CustomerID, channel)
+	}
+
+	var message interface{}
+	var messageID string
+	var digestID *uuid.UUID
+
+	if len(reminders) == 1 {
+		reminderDate := first.Reminder.ReminderDate.Format(time.RFC3339)
+
+		// One message per reminder cycle, so a rerun never queues it twice
+		messageID = first.Reminder.ID.String() + ":" + reminderDate
+		message = ReminderMessage{
+			Type:         MessageTypeReminder,
+			MessageID:    messageID,
+			ReminderID:   first.Reminder.ID.String(),
+			Recipient:    recipient,
+			OrderID:      first.Reminder.OrderID.String(),
+			ProductID:    first.Reminder.ProductID.String(),
+			ReminderDate: reminderDate,
+			TraceID:      tracing.TraceID(ctx),
+		}
+	} else {
+		// The reminders are marked sent with the digest, so a rerun never picks them up again
+		id := uuid.New()
+		digestID = &id
+		messageID = "digest:" + id.String()
+
+		digest := DigestMessage{
+			Type:      MessageTypeDigest,
+			MessageID: messageID,
+			DigestID:  id.String(),
+			Recipient: recipient,
+			TraceID:   tracing.TraceID(ctx),
+		}
+		for _, reminder := range reminders {
+			digest.Items = append(digest.Items, DigestItem{
+				ReminderID:   reminder.Reminder.ID.String(),
+				OrderID:      reminder.Reminder.OrderID.String(),
+				ProductID:    reminder.Reminder.ProductID.String(),
+				Product:      reminder.Product,
+				ReminderDate: reminder.Reminder.ReminderDate.Format(time.RFC3339),
+			})
+		}
+		message = digest
+	}
+
+	span.SetAttributes(attribute.String("message_id", messageID))
+
+	// Serialize message to JSON
+	messageBody, err := json.Marshal(message)
+	if err != nil {
+		utils.ErrorContext(ctx, "Failed to marshal reminder message", map[string]interface{}{
+			"error": err,
+		})
+		s.recordFailures(ctx, reminders, err)
+		return nil
+	}
+
+	// The payload carries the recipient's contact details
+	payload, err := s.cipher.Encrypt(ctx, string(messageBody))
+	if err != nil {
+		utils.ErrorContext(ctx, "Failed to encrypt reminder message", map[string]interface{}{
+			"error": err,
+		})
+		s.recordFailures(ctx, reminders, err)
+		return nil
+	}
+
+	outboxMessage := &models.OutboxMessage{
+		CustomerID:  first.Reminder.CustomerID,
+		DigestID:    digestID,
+		Channels:    strings.Join(recipient.Channels(), ","),
+		DedupeKey:   messageID,
+		Payload:     payload,
+		TraceParent: tracing.Inject(ctx),
+	}
+	if digestID == nil {
+		outboxMessage.ReminderID = &first.Reminder.ID
+	}
+
+	return &repositories.OutboxEntry{
+		Reminders: toReminders(reminders),
+		Message:   outboxMessage,
+	}
+}
+
+func toReminders(reminders []repositories.ReminderWithCustomer) []*models.Reminder {
+	result := make([]*models.Reminder, len(reminders))
+	for i := range reminders {
+		result[i] = &reminders[i].Reminder
+	}
+	return result
+}
+
+// skip closes the current cycle of each reminder without sending it
+func (s *reminderService) skip(ctx context.Context, reminders []repositories.ReminderWithCustomer, status string) {
+	countReminders(metrics.OutcomeSkipped, reminders)
+
+	for _, reminder := range reminders {
+		if err := s.reminderRepo.SkipReminder(ctx, &reminder.Reminder, status); err != nil {
+			utils.ErrorContext(ctx, "Failed to log skipped reminder", map[string]interface{}{
+				"error":       err,
+				"reminder_id": reminder.Reminder.ID.String(),
+				"status":      status,
+			})
+			continue
+		}
+
+		utils.InfoContext(ctx, "Reminder skipped", map[string]interface{}{
+			"reminder_id": reminder.Reminder.ID.String(),
+			"status":      status,
+		})
+		s.publishLog(ctx, &reminder.Reminder, "", status)
+	}
+}
+
+// publishLog tells watchers a log entry was appended for the reminder
+func (s *reminderService) publishLog(ctx context.Context, reminder *models.Reminder, messageID string, status string) {
+	s.bus.Publish(ctx, eventbus.Event{
+		Type:       eventbus.ReminderLogAppend,
+		ReminderID: reminder.ID.String(),
+		CustomerID: reminder.CustomerID.String(),
+		MessageID:  messageID,
+		Status:     status,
+	})
+}
+
+func (s *reminderService) recordFailures(ctx context.Context, reminders []repositories.ReminderWithCustomer, dispatchErr error) {
+	for _, reminder := range reminders {
+		s.recordFailure(ctx, &reminder.Reminder, dispatchErr)
+	}
+}
+
+// recordFailure schedules the next attempt for a reminder, or dead-letters it
+// once the retry policy gives up
+func (s *reminderService) recordFailure(ctx context.Context, reminder *models.Reminder, dispatchErr error) {
+	attempts := reminder.Attempts + 1
+
+	var nextAttemptAt *time.Time
+	if next, ok := s.retryPolicy.NextAttempt(attempts); ok {
+		nextAttemptAt = &next
+	}
+
+	if nextAttemptAt == nil {
+		utils.WarnContext(ctx, "Reminder dead-lettered", map[string]interface{}{
+			"reminder_id": reminder.ID.String(),
+			"attempts":    attempts,
+		})
+	}
+
+	lastError := redact.Text(dispatchErr.Error())
+	err := s.reminderRepo.RecordReminderFailure(ctx, reminder.ID.String(), attempts, nextAttemptAt, lastError)
+	if err != nil {
+		utils.ErrorContext(ctx, "Failed to record reminder failure", map[string]interface{}{
+			"error":       err,
+			"reminder_id": reminder.ID.String(),
+		})
+	}
+
+	s.bus.Publish(ctx, eventbus.Event{
+		Type:       eventbus.ReminderFailed,
+		ReminderID: reminder.ID.String(),
+		CustomerID: reminder.CustomerID.String(),
+		Status:     lastError,
+	})
+}
+
+// dropSuppressedContacts clears every contact address of the recipient that is
+// on the suppression list
+func (s *reminderService) dropSuppressedContacts(ctx context.Context, recipient *Recipient) error {
+	if recipient.Email != "" {
+		suppressed, err := s.suppressionRepo.IsSuppressed(ctx, models.ChannelEmail, models.NormalizeAddress(models.ChannelEmail, recipient.Email))
+		if err != nil {
+			return err
+		}
+		if suppressed {
+			recipient.Email = ""
+		}
+	}
+
+	if recipient.Phone != "" {
+		suppressed, err := s.suppressionRepo.IsSuppressed(ctx, models.ChannelSMS, models.NormalizeAddress(models.ChannelSMS, recipient.Phone))
+		if err != nil {
+			return err
+		}
+		if suppressed {
+			recipient.Phone = ""
+		}
+	}
+
+	return nil
+}
+
+// dropUnconsentedContacts clears every contact address of the recipient whose
+// channel the customer has not currently consented to
+func (s *reminderService) dropUnconsentedContacts(ctx context.Context, recipient *Recipient) error {
+	consents, err := s.consentRepo.GetCurrentConsents(ctx, recipient.CustomerID)
+	if err != nil {
+		return err
+	}
+
+	if !consents[models.ChannelEmail] {
+		recipient.Email = ""
+	}
+	if !consents[models.ChannelSMS] {
+		recipient.Phone = ""
+	}
+	return nil
+}
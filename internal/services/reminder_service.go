@@ -1,65 +1,129 @@
 package services
 
 import (
-	"encoding/json"
-	"fmt"
+	"context"
+	"sync"
 	"time"
 
+	"github.com/PharmaKart/reminder-svc/internal/auth"
+	"github.com/PharmaKart/reminder-svc/internal/encryption"
+	"github.com/PharmaKart/reminder-svc/internal/eventbus"
 	"github.com/PharmaKart/reminder-svc/internal/models"
 	"github.com/PharmaKart/reminder-svc/internal/repositories"
+	"github.com/PharmaKart/reminder-svc/internal/tracing"
 	"github.com/PharmaKart/reminder-svc/pkg/config"
 	"github.com/PharmaKart/reminder-svc/pkg/errors"
-	"github.com/PharmaKart/reminder-svc/pkg/utils"
 	"github.com/google/uuid"
+	"github.com/robfig/cron/v3"
 )
 
 type ReminderService interface {
-	ScheduleReminder(customerID, orderID string, productID string, reminderDate string) error
-	GetPendingReminders() ([]repositories.ReminderWithCustomer, error)
-	ListReminders(filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error)
-	ListCustomerReminders(customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error)
-	ListReminderLogs(reminderID string, customerId string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error)
-	UpdateReminder(reminderID string, customerId string, orderID string, reminderDate string) error
-	DeleteReminder(reminderID string, customerId string) error
-	ToggleReminder(reminderID string, customerId string) error
-	StartReminderService(cfg *config.Config)
+	ScheduleReminder(ctx context.Context, customerID, orderID string, productID string, reminderDate string) error
+	GetPendingReminders(ctx context.Context, after *repositories.ReminderCursor, limit int) ([]repositories.ReminderWithCustomer, error)
+	ListReminders(ctx context.Context, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error)
+	ListCustomerReminders(ctx context.Context, customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error)
+	ListReminderLogs(ctx context.Context, reminderID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error)
+	GetReminder(ctx context.Context, reminderID string, logLimit int32) (*ReminderDetails, error)
+	ListAllReminderLogs(ctx context.Context, customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error)
+	UpdateReminder(ctx context.Context, reminderID string, orderID string, reminderDate string) error
+	DeleteReminder(ctx context.Context, reminderID string) error
+	ToggleReminder(ctx context.Context, reminderID string) error
+	OrderPlaced(ctx context.Context, customerID, orderID string, productID string, supplyDays int32) (*models.Reminder, error)
+	OrderCreated(ctx context.Context, customerID, orderID string, productID string, supplyDays int32, orderedAt time.Time) error
+	CancelOrderReminders(ctx context.Context, orderID string) error
+	ListDeadLetteredReminders(ctx context.Context, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error)
+	RequeueReminder(ctx context.Context, reminderID string) error
+	ReportDeliveryStatus(ctx context.Context, messageID string, status string, channel string, address string, provider string, detail string, occurredAt string) error
+	StartReminderService(ctx context.Context, cfg *config.Config)
+	RetryFailedReminders(ctx context.Context, cfg *config.Config)
+	DispatchRuns(ctx context.Context) []DispatchRun
+	WatchReminderEvents(ctx context.Context, customerID, reminderID string) (<-chan eventbus.Event, func(), error)
+}
+
+// DispatchSchedule is when due reminders are dispatched
+const DispatchSchedule = "0 0 * * *"
+
+const (
+	defaultRecentLogs = 5
+	maxRecentLogs     = 50
+)
+
+// ReminderDetails is a reminder with its customer's contact details, its next
+// send time and its most recent log entries
+type ReminderDetails struct {
+	repositories.ReminderWithCustomer
+	// Nil when no send is pending, e.g. for a disabled reminder or one already
+	// sent for its current reminder date
+	NextSendAt *time.Time
+	RecentLogs []models.ReminderLog
 }
 
 type reminderService struct {
 	reminderRepo    repositories.ReminderRepository
 	reminderLogRepo repositories.ReminderLogRepository
+	outboxRepo      repositories.OutboxRepository
+	suppressionRepo repositories.SuppressionRepository
+	consentRepo     repositories.ConsentRepository
+	preferenceRepo  repositories.PreferenceRepository
+	auditRepo       repositories.AuditRepository
+	transactor      repositories.Transactor
+	retryPolicy     RetryPolicy
+	cipher          *encryption.Cipher
+	bus             eventbus.Bus
+
+	runsMu sync.Mutex
+	runs   map[string]DispatchRun
 }
 
-func NewReminderService(reminderRepo repositories.ReminderRepository, reminderLogRepo repositories.ReminderLogRepository) ReminderService {
+func NewReminderService(reminderRepo repositories.ReminderRepository, reminderLogRepo repositories.ReminderLogRepository, outboxRepo repositories.OutboxRepository, suppressionRepo repositories.SuppressionRepository, consentRepo repositories.ConsentRepository, preferenceRepo repositories.PreferenceRepository, auditRepo repositories.AuditRepository, transactor repositories.Transactor, retryPolicy RetryPolicy, cipher *encryption.Cipher, bus eventbus.Bus) ReminderService {
 	return &reminderService{
 		reminderRepo:    reminderRepo,
 		reminderLogRepo: reminderLogRepo,
+		outboxRepo:      outboxRepo,
+		suppressionRepo: suppressionRepo,
+		consentRepo:     consentRepo,
+		preferenceRepo:  preferenceRepo,
+		auditRepo:       auditRepo,
+		transactor:      transactor,
+		retryPolicy:     retryPolicy,
+		cipher:          cipher,
+		bus:             bus,
+		runs:            make(map[string]DispatchRun),
 	}
 }
 
-func (s *reminderService) ScheduleReminder(customerID, orderID string, productID string, reminderDate string) error {
+func (s *reminderService) ScheduleReminder(ctx context.Context, customerID, orderID string, productID string, reminderDate string) error {
+	ctx, span := tracing.Start(ctx, "ReminderService.ScheduleReminder")
+	defer span.End()
+
+	return s.scheduleReminder(ctx, customerID, orderID, productID, reminderDate, 0)
+}
+
+// scheduleReminder creates a reminder for the order, which adds supplyDays to
+// the reminder date
+func (s *reminderService) scheduleReminder(ctx context.Context, customerID, orderID string, productID string, reminderDate string, supplyDays int) error {
 	customer_id, err := uuid.Parse(customerID)
 	if err != nil {
-		return errors.NewInternalError(err)
+		return errors.NewValidationError("customer_id", "must be a UUID")
 	}
 
 	order_id, err := uuid.Parse(orderID)
 	if err != nil {
-		return errors.NewInternalError(err)
+		return errors.NewValidationError("order_id", "must be a UUID")
 	}
 
 	product_id, err := uuid.Parse(productID)
 	if err != nil {
-		return errors.NewInternalError(err)
+		return errors.NewValidationError("product_id", "must be a UUID")
 	}
 
 	reminder_date, err := time.Parse(time.RFC3339, reminderDate)
 	if err != nil {
-		return errors.NewInternalError(err)
+		return errors.NewValidationError("reminder_date", "must be an RFC3339 timestamp")
 	}
 
 	// Check if reminder already exists with same product and customer
-	reminderExists, err := s.reminderRepo.ReminderExists(productID, customerID)
+	reminderExists, err := s.reminderRepo.ReminderExists(ctx, productID, customerID)
 	if err != nil {
 		return errors.NewInternalError(err)
 	}
@@ -74,33 +138,52 @@ func (s *reminderService) ScheduleReminder(customerID, orderID string, productID
 		ProductID:    product_id,
 		ReminderDate: reminder_date,
 	}
-	return s.reminderRepo.ScheduleReminder(reminder)
+	return s.applyChange(ctx, models.AuditActionReminderCreated, nil, reminder, func(ctx context.Context) error {
+		return s.reminderRepo.ScheduleReminder(ctx, reminder, supplyDays)
+	})
 }
 
-func (s *reminderService) GetPendingReminders() ([]repositories.ReminderWithCustomer, error) {
-	return s.reminderRepo.GetPendingReminders()
+func (s *reminderService) GetPendingReminders(ctx context.Context, after *repositories.ReminderCursor, limit int) ([]repositories.ReminderWithCustomer, error) {
+	ctx, span := tracing.Start(ctx, "ReminderService.GetPendingReminders")
+	defer span.End()
+
+	return s.reminderRepo.GetPendingReminders(ctx, after, limit)
 }
 
-func (s *reminderService) ListReminders(filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error) {
-	return s.reminderRepo.ListReminders(filter, sortBy, sortOrder, page, limit)
+// ListReminders lets admins list reminders across customers
+func (s *reminderService) ListReminders(ctx context.Context, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error) {
+	ctx, span := tracing.Start(ctx, "ReminderService.ListReminders")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+	return s.reminderRepo.ListReminders(ctx, filter, sortBy, sortOrder, page, limit)
 }
 
-func (s *reminderService) ListCustomerReminders(customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error) {
-	return s.reminderRepo.ListCustomerReminders(customerID, filter, sortBy, sortOrder, page, limit)
+// ListCustomerReminders lists a customer's reminders. Admins can list any
+// customer's, everyone else only their own.
+func (s *reminderService) ListCustomerReminders(ctx context.Context, customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error) {
+	ctx, span := tracing.Start(ctx, "ReminderService.ListCustomerReminders")
+	defer span.End()
+
+	if err := authorizeCustomer(ctx, customerID); err != nil {
+		return nil, 0, err
+	}
+	return s.reminderRepo.ListCustomerReminders(ctx, customerID, filter, sortBy, sortOrder, page, limit)
 }
 
-func (s *reminderService) UpdateReminder(reminderID string, customerId string, orderID string, reminderDate string) error {
-	customerID, err := s.reminderRepo.GetReminderCustomer(reminderID)
+func (s *reminderService) UpdateReminder(ctx context.Context, reminderID string, orderID string, reminderDate string) error {
+	ctx, span := tracing.Start(ctx, "ReminderService.UpdateReminder")
+	defer span.End()
+
+	before, err := s.reminderRepo.GetReminder(ctx, reminderID)
 	if err != nil {
 		return err
 	}
 
-	if customerId != customerID {
-		return errors.NewAuthError("Access denied")
-	}
-	reminder_id, err := uuid.Parse(reminderID)
-	if err != nil {
-		return errors.NewInternalError(err)
+	if err := authorizeReminder(ctx, before); err != nil {
+		return err
 	}
 
 	order_id, err := uuid.Parse(orderID)
@@ -113,129 +196,500 @@ func (s *reminderService) UpdateReminder(reminderID string, customerId string, o
 		return errors.NewInternalError(err)
 	}
 
-	reminder := &models.Reminder{
-		ID:           reminder_id,
-		OrderID:      order_id,
-		ReminderDate: reminder_date,
-	}
-	return s.reminderRepo.UpdateReminder(reminder)
+	reminder := *before
+	reminder.OrderID = order_id
+	reminder.ReminderDate = reminder_date
+	return s.applyChange(ctx, models.AuditActionReminderUpdated, before, &reminder, func(ctx context.Context) error {
+		return s.reminderRepo.UpdateReminder(ctx, &reminder)
+	})
 }
 
-func (s *reminderService) DeleteReminder(reminderID string, customerId string) error {
-	customerID, err := s.reminderRepo.GetReminderCustomer(reminderID)
+func (s *reminderService) DeleteReminder(ctx context.Context, reminderID string) error {
+	ctx, span := tracing.Start(ctx, "ReminderService.DeleteReminder")
+	defer span.End()
+
+	before, err := s.reminderRepo.GetReminder(ctx, reminderID)
 	if err != nil {
 		return err
 	}
 
-	if customerId != customerID {
-		return errors.NewAuthError("Access denied")
+	if err := authorizeReminder(ctx, before); err != nil {
+		return err
 	}
 
-	return s.reminderRepo.DeleteReminder(reminderID)
+	return s.applyChange(ctx, models.AuditActionReminderDeleted, before, nil, func(ctx context.Context) error {
+		return s.reminderRepo.DeleteReminder(ctx, reminderID)
+	})
 }
 
-func (s *reminderService) ToggleReminder(reminderID string, customerId string) error {
-	customerID, err := s.reminderRepo.GetReminderCustomer(reminderID)
+func (s *reminderService) ToggleReminder(ctx context.Context, reminderID string) error {
+	ctx, span := tracing.Start(ctx, "ReminderService.ToggleReminder")
+	defer span.End()
+
+	before, err := s.reminderRepo.GetReminder(ctx, reminderID)
 	if err != nil {
 		return err
 	}
 
-	if customerId != customerID {
-		return errors.NewAuthError("Access denied")
+	if err := authorizeReminder(ctx, before); err != nil {
+		return err
 	}
 
-	return s.reminderRepo.ToggleReminder(reminderID)
+	after := *before
+	after.Enabled = !before.Enabled
+	return s.applyChange(ctx, models.AuditActionReminderToggled, before, &after, func(ctx context.Context) error {
+		return s.reminderRepo.ToggleReminder(ctx, reminderID)
+	})
 }
 
-func (s *reminderService) ListReminderLogs(reminderID string, customerId string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error) {
-	customerID, err := s.reminderRepo.GetReminderCustomer(reminderID)
+func (s *reminderService) ListReminderLogs(ctx context.Context, reminderID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error) {
+	ctx, span := tracing.Start(ctx, "ReminderService.ListReminderLogs")
+	defer span.End()
+
+	reminder, err := s.reminderRepo.GetReminder(ctx, reminderID)
 	if err != nil {
 		return nil, 0, err
 	}
 
-	if customerId != customerID {
-		return nil, 0, errors.NewAuthError("Access denied")
+	if err := authorizeReminder(ctx, reminder); err != nil {
+		return nil, 0, err
 	}
 
-	return s.reminderLogRepo.ListReminderLogs(reminderID, filter, sortBy, sortOrder, page, limit)
-}
+	logs, total, err := s.reminderLogRepo.ListReminderLogs(ctx, reminderID, filter, sortBy, sortOrder, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
 
-type ReminderMessage struct {
-	ReminderID   string `json:"reminder_id"`
-	CustomerID   string `json:"customer_id"`
-	OrderID      string `json:"order_id"`
-	ProductID    string `json:"product_id"`
-	Email        string `json:"email"`
-	Phone        string `json:"phone"`
-	ReminderDate string `json:"reminder_date"`
+	// Record whenever someone other than the customer reads their logs
+	if auth.FromContext(ctx).ID != reminder.CustomerID.String() {
+		err := recordAudit(ctx, s.auditRepo, auditEntry{
+			Action:     models.AuditActionReminderLogsRead,
+			ReminderID: &reminder.ID,
+			CustomerID: &reminder.CustomerID,
+		})
+		if err != nil {
+			return nil, 0, err
+		}
+	}
+	return logs, total, nil
 }
 
-func (s *reminderService) StartReminderService(cfg *config.Config) {
-	// Get pending reminders
-	reminders, err := s.GetPendingReminders()
+func (s *reminderService) GetReminder(ctx context.Context, reminderID string, logLimit int32) (*ReminderDetails, error) {
+	ctx, span := tracing.Start(ctx, "ReminderService.GetReminder")
+	defer span.End()
+
+	if _, err := uuid.Parse(reminderID); err != nil {
+		return nil, errors.NewValidationError("reminder_id", "must be a UUID")
+	}
+
+	if logLimit <= 0 {
+		logLimit = defaultRecentLogs
+	}
+	logLimit = min(logLimit, maxRecentLogs)
+
+	reminder, err := s.reminderRepo.GetReminderWithCustomer(ctx, reminderID)
+	if err != nil {
+		return nil, err
+	}
+
+	if err := authorizeReminder(ctx, &reminder.Reminder); err != nil {
+		return nil, err
+	}
+
+	logs, _, err := s.reminderLogRepo.ListReminderLogs(ctx, reminderID, models.Filter{}, "created_at", "desc", 1, logLimit)
 	if err != nil {
-		utils.Error("Failed to get pending reminders", map[string]interface{}{
-			"error": err,
+		return nil, err
+	}
+
+	if auth.FromContext(ctx).ID != reminder.Reminder.CustomerID.String() {
+		err := recordAudit(ctx, s.auditRepo, auditEntry{
+			Action:     models.AuditActionReminderLogsRead,
+			ReminderID: &reminder.Reminder.ID,
+			CustomerID: &reminder.Reminder.CustomerID,
 		})
-		return
-	}
-
-	// // Initialize AWS session
-	// sess, err := session.NewSession(&aws.Config{
-	// 	Region: aws.String(cfg.AWS_REGION),
-	// })
-	// if err != nil {
-	// 	utils.Error("Failed to initialize AWS session", map[string]interface{}{
-	// 		"error": err,
-	// 	})
-	// 	return
-	// }
-
-	// // Initialize SQS client
-	// sqsClient := sqs.New(sess)
-
-	// Iterate over reminders and queue messages
-	for _, reminder := range reminders {
-		message := ReminderMessage{
-			ReminderID:   reminder.Reminder.ID.String(),
-			CustomerID:   reminder.Reminder.CustomerID.String(),
-			OrderID:      reminder.Reminder.OrderID.String(),
-			ProductID:    reminder.Reminder.ProductID.String(),
-			Email:        reminder.Email,
-			Phone:        "",
-			ReminderDate: reminder.Reminder.ReminderDate.Format(time.RFC3339),
-		}
-
-		// Include phone number if available
-		if reminder.Phone != nil {
-			message.Phone = *reminder.Phone
-		}
-
-		// Serialize message to JSON
-		messageBody, err := json.Marshal(message)
 		if err != nil {
-			utils.Error("Failed to marshal reminder message", map[string]interface{}{
-				"error": err,
-			})
-			continue
-		}
-
-		// // Send message to SQS queue
-		// _, err = sqsClient.SendMessage(&sqs.SendMessageInput{
-		// 	QueueUrl:    aws.String(cfg.SQS_QUEUE_URL),
-		// 	MessageBody: aws.String(string(messageBody)),
-		// })
-		// if err != nil {
-		// 	utils.Error("Failed to send message to SQS", map[string]interface{}{
-		// 		"error": err,
-		// 	})
-		// 	continue
-		// }
-
-		utils.Info("Reminder queue", map[string]interface{}{
-			"message": string(messageBody),
+			return nil, err
+		}
+	}
+
+	return &ReminderDetails{
+		ReminderWithCustomer: *reminder,
+		NextSendAt:           s.nextSendTime(&reminder.Reminder, time.Now()),
+		RecentLogs:           logs,
+	}, nil
+}
+
+// nextSendTime estimates when the reminder is next sent: the first dispatch run
+// after its reminder date or, for a failed send, the first retry run after its
+// backoff. Rate limits can push the actual send later.
+func (s *reminderService) nextSendTime(reminder *models.Reminder, now time.Time) *time.Time {
+	if !reminder.Enabled || reminder.Status != models.ReminderStatusActive {
+		return nil
+	}
+
+	// Already sent for the current reminder date; the next one comes with a new order
+	if !reminder.LastSentAt.IsZero() && !reminder.LastSentAt.Before(reminder.ReminderDate) {
+		return nil
+	}
+
+	spec, from := DispatchSchedule, reminder.ReminderDate
+	if reminder.Attempts > 0 && reminder.NextAttemptAt != nil {
+		spec, from = s.retryPolicy.Schedule, *reminder.NextAttemptAt
+	}
+	if from.Before(now) {
+		from = now
+	}
+
+	schedule, err := cron.ParseStandard(spec)
+	if err != nil {
+		return nil
+	}
+
+	// Next is strictly after its argument, so a run at exactly from counts
+	next := schedule.Next(from.In(time.Local).Add(-time.Second))
+	return &next
+}
+
+// ListAllReminderLogs lets admins list log entries across reminders, optionally
+// for a single customer
+func (s *reminderService) ListAllReminderLogs(ctx context.Context, customerID string, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.ReminderLog, int32, error) {
+	ctx, span := tracing.Start(ctx, "ReminderService.ListAllReminderLogs")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	entry := auditEntry{Action: models.AuditActionReminderLogsRead}
+	if customerID != "" {
+		customer_id, err := uuid.Parse(customerID)
+		if err != nil {
+			return nil, 0, errors.NewValidationError("customer_id", "must be a UUID")
+		}
+		entry.CustomerID = &customer_id
+	}
+
+	logs, total, err := s.reminderLogRepo.ListAllReminderLogs(ctx, customerID, filter, sortBy, sortOrder, page, limit)
+	if err != nil {
+		return nil, 0, err
+	}
+
+	if err := recordAudit(ctx, s.auditRepo, entry); err != nil {
+		return nil, 0, err
+	}
+	return logs, total, nil
+}
+
+// OrderPlaced pushes an existing reminder back by the supply length of a new order
+// so customers who already refilled are not reminded early. Only the order
+// service, or an admin, may call it.
+func (s *reminderService) OrderPlaced(ctx context.Context, customerID, orderID string, productID string, supplyDays int32) (*models.Reminder, error) {
+	ctx, span := tracing.Start(ctx, "ReminderService.OrderPlaced")
+	defer span.End()
+
+	if err := requireService(ctx); err != nil {
+		return nil, err
+	}
+	return s.orderPlaced(ctx, customerID, orderID, productID, supplyDays)
+}
+
+func (s *reminderService) orderPlaced(ctx context.Context, customerID, orderID string, productID string, supplyDays int32) (*models.Reminder, error) {
+	if supplyDays <= 0 {
+		return nil, errors.NewValidationError("supply_days", "must be greater than zero")
+	}
+
+	order_id, err := uuid.Parse(orderID)
+	if err != nil {
+		return nil, errors.NewValidationError("order_id", "must be a UUID")
+	}
+
+	reminder, err := s.reminderRepo.GetReminderByProductAndCustomer(ctx, productID, customerID)
+	if err != nil {
+		return nil, err
+	}
+
+	var change *repositories.ReminderChange
+	err = s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		change, err = s.reminderRepo.AdvanceReminder(ctx, reminder.ID, order_id, int(supplyDays))
+		if err != nil || change == nil {
+			return err
+		}
+		return recordAudit(ctx, s.auditRepo, reminderAudit(models.AuditActionReminderRescheduled, &change.Before, &change.After))
+	})
+	if err != nil {
+		return nil, err
+	}
+
+	// The reminder has already been advanced for this order
+	if change == nil {
+		return reminder, nil
+	}
+
+	s.publishChange(ctx, &change.Before, &change.After)
+	return &change.After, nil
+}
+
+// OrderCreated schedules a reminder for a newly ordered product, or advances the
+// existing one. Replaying the same order is a no-op.
+func (s *reminderService) OrderCreated(ctx context.Context, customerID, orderID string, productID string, supplyDays int32, orderedAt time.Time) error {
+	ctx, span := tracing.Start(ctx, "ReminderService.OrderCreated")
+	defer span.End()
+
+	reminderExists, err := s.reminderRepo.ReminderExists(ctx, productID, customerID)
+	if err != nil {
+		return err
+	}
+
+	if reminderExists {
+		_, err := s.orderPlaced(ctx, customerID, orderID, productID, supplyDays)
+		return err
+	}
+
+	if supplyDays <= 0 {
+		return errors.NewValidationError("supply_days", "must be greater than zero")
+	}
+
+	reminderDate := orderedAt.AddDate(0, 0, int(supplyDays)).Format(time.RFC3339)
+	return s.scheduleReminder(ctx, customerID, orderID, productID, reminderDate, int(supplyDays))
+}
+
+// CancelOrderReminders takes a cancelled or refunded order off the reminders it
+// created or advanced. They go back to their previous order, or are disabled if
+// the order was their only one.
+func (s *reminderService) CancelOrderReminders(ctx context.Context, orderID string) error {
+	ctx, span := tracing.Start(ctx, "ReminderService.CancelOrderReminders")
+	defer span.End()
+
+	if _, err := uuid.Parse(orderID); err != nil {
+		return errors.NewValidationError("order_id", "must be a UUID")
+	}
+
+	var changes []repositories.ReminderChange
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		changes, err = s.reminderRepo.CancelOrderReminders(ctx, orderID)
+		if err != nil {
+			return err
+		}
+
+		for i := range changes {
+			change := &changes[i]
+			action := models.AuditActionReminderRescheduled
+			if !change.After.Enabled {
+				action = models.AuditActionReminderDisabled
+			}
+			if err := recordAudit(ctx, s.auditRepo, reminderAudit(action, &change.Before, &change.After)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range changes {
+		s.publishChange(ctx, &changes[i].Before, &changes[i].After)
+	}
+	return nil
+}
+
+func (s *reminderService) ListDeadLetteredReminders(ctx context.Context, sortBy string, sortOrder string, page, limit int32) ([]models.Reminder, int32, error) {
+	ctx, span := tracing.Start(ctx, "ReminderService.ListDeadLetteredReminders")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+
+	filter := models.Filter{
+		Column:   "status",
+		Operator: "eq",
+		Value:    models.ReminderStatusDeadLettered,
+	}
+	return s.reminderRepo.ListReminders(ctx, filter, sortBy, sortOrder, page, limit)
+}
+
+func (s *reminderService) RequeueReminder(ctx context.Context, reminderID string) error {
+	ctx, span := tracing.Start(ctx, "ReminderService.RequeueReminder")
+	defer span.End()
+
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	if _, err := uuid.Parse(reminderID); err != nil {
+		return errors.NewInternalError(err)
+	}
+
+	var changes []repositories.ReminderChange
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		var err error
+		changes, err = s.reminderRepo.RequeueReminder(ctx, reminderID)
+		if err != nil {
+			return err
+		}
+
+		// A digest requeues every reminder it carries
+		for i := range changes {
+			if err := recordAudit(ctx, s.auditRepo, reminderAudit(models.AuditActionReminderRequeued, &changes[i].Before, &changes[i].After)); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		return err
+	}
+
+	for i := range changes {
+		s.publishChange(ctx, &changes[i].Before, &changes[i].After)
+	}
+	return nil
+}
+
+// ReportDeliveryStatus records a delivery callback from a downstream sender
+// against the log entry of the message it refers to. Hard bounces and opt-outs
+// also suppress the recipient's address. Only services, such as the sender,
+// and admins may call it.
+func (s *reminderService) ReportDeliveryStatus(ctx context.Context, messageID string, status string, channel string, address string, provider string, detail string, occurredAt string) error {
+	ctx, span := tracing.Start(ctx, "ReminderService.ReportDeliveryStatus")
+	defer span.End()
+
+	if err := requireService(ctx); err != nil {
+		return err
+	}
+
+	if status == models.DeliveryStatusOptedOut || status == models.ReminderLogStatusBounced {
+		if !models.IsChannel(channel) {
+			return errors.NewValidationError("channel", "must be email or sms")
+		}
+		if address == "" {
+			return errors.NewValidationError("address", "is required")
+		}
+	}
+
+	// Opt-outs such as SMS STOP replies are not tied to a single message
+	if status == models.DeliveryStatusOptedOut {
+		return s.suppress(ctx, channel, address, models.SuppressionReasonStopReply)
+	}
+
+	if messageID == "" {
+		return errors.NewValidationError("message_id", "is required")
+	}
+
+	if !models.IsDeliveryStatus(status) {
+		return errors.NewValidationError("status", "is not a valid delivery status")
+	}
+
+	occurred_at := time.Now()
+	if occurredAt != "" {
+		var err error
+		occurred_at, err = time.Parse(time.RFC3339, occurredAt)
+		if err != nil {
+			return errors.NewValidationError("occurred_at", "must be an RFC3339 timestamp")
+		}
+	}
+
+	// Provider details often quote the recipient's address
+	detail, err := s.cipher.Encrypt(ctx, detail)
+	if err != nil {
+		return errors.NewInternalError(err)
+	}
+
+	event := &models.DeliveryEvent{
+		MessageID:  messageID,
+		Status:     status,
+		Provider:   provider,
+		Detail:     detail,
+		OccurredAt: occurred_at,
+	}
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.reminderLogRepo.RecordDeliveryEvent(ctx, event); err != nil {
+			return err
+		}
+
+		if status == models.ReminderLogStatusBounced {
+			return s.suppress(ctx, channel, address, models.SuppressionReasonHardBounce)
+		}
+		return nil
+	})
+}
+
+// suppress adds a suppression reported by a delivery callback and audits it
+// in the same transaction
+func (s *reminderService) suppress(ctx context.Context, channel string, address string, reason string) error {
+	suppression := &models.Suppression{
+		Channel: channel,
+		Address: models.NormalizeAddress(channel, address),
+		Reason:  reason,
+		Source:  models.SuppressionSourceDeliveryCallback,
+	}
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.suppressionRepo.AddSuppression(ctx, suppression); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.auditRepo, auditEntry{
+			Action: models.AuditActionSuppressionAdded,
+			After:  suppressionAuditFields(suppression.Channel, suppression.Address, reason),
 		})
+	})
+}
 
-		utils.Info(fmt.Sprintf("Reminder queued successfully for customer %s", reminder.Email), nil)
+// applyChange makes a change to a reminder with apply and audits it in the
+// same transaction, then pushes it to watchers. A nil before records a created
+// reminder and a nil after a deleted one.
+func (s *reminderService) applyChange(ctx context.Context, action string, before, after *models.Reminder, apply func(ctx context.Context) error) error {
+	err := s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := apply(ctx); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.auditRepo, reminderAudit(action, before, after))
+	})
+	if err != nil {
+		return err
 	}
+
+	s.publishChange(ctx, before, after)
+	return nil
+}
+
+// publishChange pushes a committed change to a reminder to watchers
+func (s *reminderService) publishChange(ctx context.Context, before, after *models.Reminder) {
+	eventType := eventbus.ReminderUpdated
+	subject := after
+	switch {
+	case before == nil:
+		eventType = eventbus.ReminderCreated
+	case after == nil:
+		eventType = eventbus.ReminderDeleted
+		subject = before
+	}
+
+	s.bus.Publish(ctx, eventbus.Event{
+		Type:       eventType,
+		ReminderID: subject.ID.String(),
+		CustomerID: subject.CustomerID.String(),
+	})
+}
+
+// WatchReminderEvents subscribes to reminder events. Admins can watch every
+// customer; everyone else only their own reminders.
+func (s *reminderService) WatchReminderEvents(ctx context.Context, customerID, reminderID string) (<-chan eventbus.Event, func(), error) {
+	principal := auth.FromContext(ctx)
+	if !principal.IsAdmin() && (customerID == "" || customerID != principal.ID) {
+		return nil, nil, errors.NewAuthError("Access denied")
+	}
+
+	if reminderID != "" {
+		if _, err := uuid.Parse(reminderID); err != nil {
+			return nil, nil, errors.NewValidationError("reminder_id", "must be a UUID")
+		}
+	}
+
+	events, cancel := s.bus.Subscribe(eventbus.Filter{
+		CustomerID: customerID,
+		ReminderID: reminderID,
+	})
+	return events, cancel, nil
 }
@@ -0,0 +1,46 @@
+package services
+
+import (
+	"math/rand/v2"
+	"time"
+
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+)
+
+// RetryPolicy decides when a failed send is retried and when it is dead-lettered
+type RetryPolicy struct {
+	MaxAttempts int
+	BaseDelay   time.Duration
+	MaxDelay    time.Duration
+	// Schedule of the job that retries failed reminders once their backoff has elapsed
+	Schedule string
+}
+
+func NewRetryPolicy(cfg *config.Config) RetryPolicy {
+	return RetryPolicy{
+		MaxAttempts: cfg.RETRY_MAX_ATTEMPTS,
+		BaseDelay:   cfg.RETRY_BASE_DELAY,
+		MaxDelay:    cfg.RETRY_MAX_DELAY,
+		Schedule:    cfg.RETRY_SCHEDULE,
+	}
+}
+
+// NextAttempt returns when to retry after the given number of failed attempts,
+// or false once the attempts are exhausted
+func (p RetryPolicy) NextAttempt(attempts int) (time.Time, bool) {
+	if attempts >= p.MaxAttempts {
+		return time.Time{}, false
+	}
+
+	// Double the delay per attempt, capped at MaxDelay
+	delay := p.MaxDelay
+	if shift := attempts - 1; shift < 32 {
+		if d := p.BaseDelay << shift; d > 0 && d < p.MaxDelay {
+			delay = d
+		}
+	}
+
+	// Keep half the delay and randomise the rest so retries don't arrive in lockstep
+	jitter := time.Duration(rand.Int64N(int64(delay/2) + 1))
+	return time.Now().Add(delay/2 + jitter), true
+}
@@ -0,0 +1,105 @@
+package services
+
+import (
+	"context"
+
+	"github.com/PharmaKart/reminder-svc/internal/models"
+	"github.com/PharmaKart/reminder-svc/internal/repositories"
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+	"github.com/PharmaKart/reminder-svc/pkg/redact"
+)
+
+type SuppressionService interface {
+	AddSuppression(ctx context.Context, channel string, address string, reason string) error
+	RemoveSuppression(ctx context.Context, channel string, address string) error
+	ListSuppressions(ctx context.Context, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Suppression, int32, error)
+}
+
+type suppressionService struct {
+	suppressionRepo repositories.SuppressionRepository
+	auditRepo       repositories.AuditRepository
+	transactor      repositories.Transactor
+}
+
+func NewSuppressionService(suppressionRepo repositories.SuppressionRepository, auditRepo repositories.AuditRepository, transactor repositories.Transactor) SuppressionService {
+	return &suppressionService{
+		suppressionRepo: suppressionRepo,
+		auditRepo:       auditRepo,
+		transactor:      transactor,
+	}
+}
+
+func (s *suppressionService) AddSuppression(ctx context.Context, channel string, address string, reason string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	if !models.IsChannel(channel) {
+		return errors.NewValidationError("channel", "must be email or sms")
+	}
+
+	if address == "" {
+		return errors.NewValidationError("address", "is required")
+	}
+
+	if reason == "" {
+		reason = models.SuppressionReasonAdmin
+	}
+
+	suppression := &models.Suppression{
+		Channel: channel,
+		Address: models.NormalizeAddress(channel, address),
+		Reason:  reason,
+		Source:  models.SuppressionSourceAdmin,
+	}
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.suppressionRepo.AddSuppression(ctx, suppression); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.auditRepo, auditEntry{
+			Action: models.AuditActionSuppressionAdded,
+			After:  suppressionAuditFields(suppression.Channel, suppression.Address, reason),
+		})
+	})
+}
+
+func (s *suppressionService) RemoveSuppression(ctx context.Context, channel string, address string) error {
+	if err := requireAdmin(ctx); err != nil {
+		return err
+	}
+
+	if !models.IsChannel(channel) {
+		return errors.NewValidationError("channel", "must be email or sms")
+	}
+
+	address = models.NormalizeAddress(channel, address)
+	return s.transactor.WithTransaction(ctx, func(ctx context.Context) error {
+		if err := s.suppressionRepo.RemoveSuppression(ctx, channel, address); err != nil {
+			return err
+		}
+		return recordAudit(ctx, s.auditRepo, auditEntry{
+			Action: models.AuditActionSuppressionRemoved,
+			Before: suppressionAuditFields(channel, address, ""),
+		})
+	})
+}
+
+// suppressionAuditFields identifies a suppression in the audit trail. The
+// address is masked, as the audit trail outlives the suppression.
+func suppressionAuditFields(channel, address, reason string) map[string]interface{} {
+	fields := map[string]interface{}{
+		"channel": channel,
+		"address": redact.Address(address),
+	}
+	if reason != "" {
+		fields["reason"] = reason
+	}
+	return fields
+}
+
+func (s *suppressionService) ListSuppressions(ctx context.Context, filter models.Filter, sortBy string, sortOrder string, page, limit int32) ([]models.Suppression, int32, error) {
+	if err := requireAdmin(ctx); err != nil {
+		return nil, 0, err
+	}
+	return s.suppressionRepo.ListSuppressions(ctx, filter, sortBy, sortOrder, page, limit)
+}
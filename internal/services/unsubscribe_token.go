@@ -0,0 +1,42 @@
+package services
+
+import (
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"strings"
+
+	"github.com/PharmaKart/reminder-svc/pkg/errors"
+)
+
+// newUnsubscribeToken signs the customer and channel so the token can be put in
+// a message and redeemed without authentication
+func newUnsubscribeToken(secret string, customerID string, channel string) string {
+	payload := base64.RawURLEncoding.EncodeToString([]byte(customerID + "|" + channel))
+	return payload + "." + signUnsubscribePayload(secret, payload)
+}
+
+// parseUnsubscribeToken verifies a token and returns the customer and channel it was issued for
+func parseUnsubscribeToken(secret string, token string) (string, string, error) {
+	payload, signature, found := strings.Cut(token, ".")
+	if !found || !hmac.Equal([]byte(signature), []byte(signUnsubscribePayload(secret, payload))) {
+		return "", "", errors.NewValidationError("token", "is invalid")
+	}
+
+	decoded, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return "", "", errors.NewValidationError("token", "is invalid")
+	}
+
+	customerID, channel, found := strings.Cut(string(decoded), "|")
+	if !found {
+		return "", "", errors.NewValidationError("token", "is invalid")
+	}
+	return customerID, channel, nil
+}
+
+func signUnsubscribePayload(secret string, payload string) string {
+	mac := hmac.New(sha256.New, []byte(secret))
+	mac.Write([]byte(payload))
+	return base64.RawURLEncoding.EncodeToString(mac.Sum(nil))
+}
@@ -0,0 +1,53 @@
+package tracing
+
+import (
+	"github.com/PharmaKart/reminder-svc/pkg/utils"
+	"go.opentelemetry.io/otel/attribute"
+	"go.opentelemetry.io/otel/trace"
+	"gorm.io/gorm"
+)
+
+const spanKey = "tracing:span"
+
+// InstrumentDB starts a span for every query run through db with a context
+func InstrumentDB(db *gorm.DB) error {
+	return utils.RegisterQueryCallbacks(db, "tracing", startQuerySpan, endQuerySpan)
+}
+
+func startQuerySpan(db *gorm.DB, operation string) {
+	ctx := db.Statement.Context
+	if ctx == nil || !trace.SpanContextFromContext(ctx).IsValid() {
+		// Only trace queries that belong to a traced request or job
+		return
+	}
+
+	_, span := tracer.Start(ctx, "gorm."+operation,
+		trace.WithSpanKind(trace.SpanKindClient),
+		trace.WithAttributes(
+			attribute.String("db.system", "postgresql"),
+			attribute.String("db.operation", operation),
+			attribute.String("db.sql.table", db.Statement.Table),
+		),
+	)
+	db.InstanceSet(spanKey, span)
+}
+
+func endQuerySpan(db *gorm.DB, operation string) {
+	value, ok := db.InstanceGet(spanKey)
+	if !ok {
+		return
+	}
+	span, ok := value.(trace.Span)
+	if !ok {
+		return
+	}
+
+	span.SetAttributes(
+		attribute.String("db.statement", db.Statement.SQL.String()),
+		attribute.Int64("db.rows_affected", db.Statement.RowsAffected),
+	)
+	if db.Error != gorm.ErrRecordNotFound {
+		RecordError(span, db.Error)
+	}
+	span.End()
+}
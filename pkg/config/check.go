@@ -0,0 +1,51 @@
+package config
+
+import (
+	"io"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// redacted replaces secrets that are set in the output of Print
+const redacted = "REDACTED"
+
+// Print writes the effective configuration as a config file, with each value's
+// source as a comment. Secrets are redacted.
+func (c *Config) Print(w io.Writer) error {
+	root := &yaml.Node{Kind: yaml.MappingNode}
+
+	for _, section := range sections {
+		entries := &yaml.Node{Kind: yaml.MappingNode}
+
+		for _, s := range settings {
+			name, key, _ := strings.Cut(s.path, ".")
+			if name != section {
+				continue
+			}
+
+			var v yaml.Node
+			if s.secret {
+				secret := ""
+				if s.get(c) != "" {
+					secret = redacted
+				}
+				v.SetString(secret)
+			} else if err := v.Encode(s.get(c)); err != nil {
+				return err
+			}
+			v.LineComment = c.sources[s.env]
+
+			entries.Content = append(entries.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: key}, &v)
+		}
+
+		root.Content = append(root.Content, &yaml.Node{Kind: yaml.ScalarNode, Value: section}, entries)
+	}
+
+	encoder := yaml.NewEncoder(w)
+	encoder.SetIndent(2)
+	if err := encoder.Encode(root); err != nil {
+		return err
+	}
+	return encoder.Close()
+}
@@ -1,57 +1,184 @@
 package config
 
 import (
+	"errors"
 	"fmt"
 	"log"
+	"net"
+	"net/url"
 	"os"
+	"strings"
+	"time"
 
 	"github.com/joho/godotenv"
 )
 
 // Config struct
 type Config struct {
-	Port                  string
-	DBConnString          string
-	AWS_ACCESS_KEY_ID     string
-	AWS_SECRET_ACCESS_KEY string
-	AWS_REGION            string
-	SQS_QUEUE_URL         string
-	SNS_TOPIC_ARN         string
+	Port                        string
+	GATEWAY_PORT                string
+	GATEWAY_WATCH_CONNS         int
+	METRICS_PORT                string
+	RPC_TIMEOUT                 time.Duration
+	SHUTDOWN_TIMEOUT            time.Duration
+	GRPC_REFLECTION             bool
+	GRPC_KEEPALIVE_TIME         time.Duration
+	GRPC_KEEPALIVE_TIMEOUT      time.Duration
+	GRPC_KEEPALIVE_MIN_TIME     time.Duration
+	GRPC_MAX_CONCURRENT_STREAMS int
+	GRPC_MAX_RECV_MSG_SIZE      int
+	GRPC_MAX_SEND_MSG_SIZE      int
+	GRPC_TLS_CERT_FILE          string
+	GRPC_TLS_KEY_FILE           string
+	GRPC_TLS_CLIENT_CA_FILE     string
+	GRPC_TLS_RELOAD_INTERVAL    time.Duration
+	AUTH_TOKEN_SECRET           string
+	DB_HOST                     string
+	DB_PORT                     string
+	DB_USER                     string
+	DB_PASSWORD                 string
+	DB_NAME                     string
+	DB_SSLMODE                  string
+	DB_MAX_OPEN_CONNS           int
+	DB_MAX_IDLE_CONNS           int
+	DB_CONN_MAX_LIFETIME        time.Duration
+	DB_CONN_MAX_IDLE_TIME       time.Duration
+	DB_STATEMENT_TIMEOUT        time.Duration
+	DB_CONNECT_TIMEOUT          time.Duration
+	DB_PREPARED_STATEMENTS      bool
+	DB_REPLICA_HOSTS            string
+	// DBConnString and DBReplicaConnStrings are built from the DB_ settings
+	DBConnString           string
+	DBReplicaConnStrings   []string
+	AWS_REGION             string
+	AWS_ACCESS_KEY_ID      string
+	AWS_SECRET_ACCESS_KEY  string
+	DISPATCHER             string
+	SQS_QUEUE_URL          string
+	SNS_TOPIC_ARN          string
+	DISPATCH_BATCH_SIZE    int
+	DISPATCH_CONCURRENCY   int
+	RATE_LIMIT_GLOBAL      string
+	RATE_LIMIT_CHANNEL     string
+	RATE_LIMIT_CUSTOMER    string
+	OUTBOX_RELAY_SCHEDULE  string
+	RETRY_SCHEDULE         string
+	HEALTH_CHECK_INTERVAL  time.Duration
+	RETRY_MAX_ATTEMPTS     int
+	RETRY_BASE_DELAY       time.Duration
+	RETRY_MAX_DELAY        time.Duration
+	REQUIRE_CONSENT        bool
+	UNSUBSCRIBE_SECRET     string
+	REMINDER_EVENTS_BUS    string
+	EVENT_SOURCE           string
+	ORDER_EVENTS_QUEUE_URL string
+	EVENTS_WEBHOOK_PORT    string
+	EVENTS_WEBHOOK_SECRET  string
+	LOG_LEVEL              string
+	LOG_FORMAT             string
+	TRACING_EXPORTER       string
+	TRACING_OTLP_ENDPOINT  string
+	TRACING_OTLP_INSECURE  bool
+	ENCRYPTION_KEYS        string
+	ENCRYPTION_KEY_ID      string
+
+	// sources records where each setting came from, by env name
+	sources map[string]string
 }
 
-// LoadConfig loads the configuration from .env file
-func LoadConfig() *Config {
+// Load builds the configuration from, in increasing precedence: defaults, the
+// YAML file named by --config or CONFIG_FILE, environment variables (including
+// a .env file) and the command-line flags in args. Secrets can also be read
+// from files. The result is validated, and every problem is reported at once.
+func Load(args []string) (*Config, error) {
 	// Load environment variables from .env file
-	if err := godotenv.Overload(); err != nil {
+	if err := godotenv.Overload(); err != nil && !errors.Is(err, os.ErrNotExist) {
+		return nil, fmt.Errorf(".env: %w", err)
+	} else if err != nil {
 		log.Println("No .env file found, using system environment variables")
 	}
 
-	return &Config{
-		Port:                  getEnv("PORT", "50055"),
-		DBConnString:          getDBConnString(),
-		AWS_ACCESS_KEY_ID:     getEnv("AWS_ACCESS_KEY_ID", "aws-access-key"),
-		AWS_SECRET_ACCESS_KEY: getEnv("AWS_SECRET_ACCESS_KEY", "your-aws-secret-key"),
-		AWS_REGION:            getEnv("AWS_REGION", "ca-central-1"),
-		SNS_TOPIC_ARN:         getEnv("SNS_TOPIC_ARN", "your-sns-topic-arn"),
-		SQS_QUEUE_URL:         getEnv("SQS_QUEUE_URL", "your-sqs-queue-url"),
+	flags, configFile, err := parseFlags(args)
+	if err != nil {
+		return nil, err
+	}
+	if configFile == "" {
+		configFile = os.Getenv("CONFIG_FILE")
+	}
+
+	cfg := &Config{sources: make(map[string]string)}
+	var errs []error
+
+	for _, s := range settings {
+		if err := s.set(cfg, s.def); err != nil {
+			return nil, fmt.Errorf("default for %s: %w", s.env, err)
+		}
+		cfg.sources[s.env] = "default"
+	}
+
+	layers := []func() (map[string]value, error){
+		func() (map[string]value, error) { return fileValues(configFile) },
+		envValues,
+		func() (map[string]value, error) { return flags, nil },
 	}
+	for _, layer := range layers {
+		values, err := layer()
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+
+		for _, s := range settings {
+			v, ok := values[s.env]
+			if !ok {
+				continue
+			}
+			if err := s.set(cfg, v.value); err != nil {
+				errs = append(errs, fmt.Errorf("%s (from %s): %w", s.env, v.source, err))
+				continue
+			}
+			cfg.sources[s.env] = v.source
+		}
+	}
+
+	errs = append(errs, cfg.validate()...)
+	if err := errors.Join(errs...); err != nil {
+		return nil, err
+	}
+
+	cfg.DBConnString = cfg.dbConnString(cfg.DB_HOST + ":" + cfg.DB_PORT)
+	for _, host := range cfg.replicaHosts() {
+		cfg.DBReplicaConnStrings = append(cfg.DBReplicaConnStrings, cfg.dbConnString(host))
+	}
+	return cfg, nil
 }
 
-func getDBConnString() string {
-	return fmt.Sprintf(
-		"postgres://%s:%s@%s:%s/%s?sslmode=prefer",
-		getEnv("DB_USER", "postgres"),
-		getEnv("DB_PASSWORD", "postgres"),
-		getEnv("DB_HOST", "localhost"),
-		getEnv("DB_PORT", "5432"),
-		getEnv("DB_NAME", "pharmakartdb"),
-	)
+func (c *Config) dbConnString(host string) string {
+	params := url.Values{"sslmode": {c.DB_SSLMODE}}
+	dsn := url.URL{
+		Scheme:   "postgres",
+		User:     url.UserPassword(c.DB_USER, c.DB_PASSWORD),
+		Host:     host,
+		Path:     c.DB_NAME,
+		RawQuery: params.Encode(),
+	}
+	return dsn.String()
 }
 
-func getEnv(key, defaultValue string) string {
-	value := os.Getenv(key)
-	if value == "" {
-		return defaultValue
+// replicaHosts returns the DB_REPLICA_HOSTS entries as host:port, using
+// DB_PORT when an entry is a bare host or IP address. Other entries are
+// returned as given for validate to reject.
+func (c *Config) replicaHosts() []string {
+	var hosts []string
+	for _, host := range strings.Split(c.DB_REPLICA_HOSTS, ",") {
+		host = strings.TrimSpace(host)
+		if host == "" {
+			continue
+		}
+		if _, _, err := net.SplitHostPort(host); err != nil && (!strings.Contains(host, ":") || net.ParseIP(host) != nil) {
+			host = net.JoinHostPort(host, c.DB_PORT)
+		}
+		hosts = append(hosts, host)
 	}
-	return value
+	return hosts
 }
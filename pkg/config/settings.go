@@ -0,0 +1,215 @@
+package config
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// setting describes one configuration value. It is read from the env var env,
+// the key path (section.key) in the config file and the flag named after env,
+// e.g. --db-host for DB_HOST.
+type setting struct {
+	env   string
+	path  string
+	def   string
+	usage string
+	// secret values can be read from a file and are redacted by config check
+	secret bool
+	field  func(c *Config) interface{}
+}
+
+// sections are the top-level keys of the config file, in the order config
+// check prints them
+var sections = []string{"server", "tls", "auth", "database", "aws", "dispatcher", "cron", "reminders", "events", "logging", "tracing", "encryption"}
+
+var settings = []setting{
+	{env: "PORT", path: "server.port", def: "50055", usage: "gRPC port",
+		field: func(c *Config) interface{} { return &c.Port }},
+	{env: "GATEWAY_PORT", path: "server.gateway_port", usage: "HTTP/JSON gateway port, empty to disable",
+		field: func(c *Config) interface{} { return &c.GATEWAY_PORT }},
+	{env: "GATEWAY_WATCH_CONNS", path: "server.gateway_watch_conns", def: "4", usage: "connections the gateway opens for watch streams",
+		field: func(c *Config) interface{} { return &c.GATEWAY_WATCH_CONNS }},
+	{env: "METRICS_PORT", path: "server.metrics_port", def: "9090", usage: "Prometheus metrics port, empty to disable",
+		field: func(c *Config) interface{} { return &c.METRICS_PORT }},
+	{env: "RPC_TIMEOUT", path: "server.rpc_timeout", def: "10s", usage: "deadline for RPCs without a shorter one",
+		field: func(c *Config) interface{} { return &c.RPC_TIMEOUT }},
+	{env: "SHUTDOWN_TIMEOUT", path: "server.shutdown_timeout", def: "30s", usage: "time allowed to drain on shutdown",
+		field: func(c *Config) interface{} { return &c.SHUTDOWN_TIMEOUT }},
+	{env: "GRPC_REFLECTION", path: "server.reflection", def: "false", usage: "register the gRPC reflection service",
+		field: func(c *Config) interface{} { return &c.GRPC_REFLECTION }},
+	{env: "GRPC_KEEPALIVE_TIME", path: "server.keepalive_time", def: "5m", usage: "ping idle connections this often",
+		field: func(c *Config) interface{} { return &c.GRPC_KEEPALIVE_TIME }},
+	{env: "GRPC_KEEPALIVE_TIMEOUT", path: "server.keepalive_timeout", def: "20s", usage: "close connections that do not answer a ping in time",
+		field: func(c *Config) interface{} { return &c.GRPC_KEEPALIVE_TIMEOUT }},
+	{env: "GRPC_KEEPALIVE_MIN_TIME", path: "server.keepalive_min_time", def: "30s", usage: "disconnect clients that ping more often",
+		field: func(c *Config) interface{} { return &c.GRPC_KEEPALIVE_MIN_TIME }},
+	{env: "GRPC_MAX_CONCURRENT_STREAMS", path: "server.max_concurrent_streams", def: "100", usage: "calls in flight per connection, 0 for no limit",
+		field: func(c *Config) interface{} { return &c.GRPC_MAX_CONCURRENT_STREAMS }},
+	{env: "GRPC_MAX_RECV_MSG_SIZE", path: "server.max_recv_msg_size", def: "4194304", usage: "largest request in bytes",
+		field: func(c *Config) interface{} { return &c.GRPC_MAX_RECV_MSG_SIZE }},
+	{env: "GRPC_MAX_SEND_MSG_SIZE", path: "server.max_send_msg_size", def: "4194304", usage: "largest response in bytes",
+		field: func(c *Config) interface{} { return &c.GRPC_MAX_SEND_MSG_SIZE }},
+
+	{env: "GRPC_TLS_CERT_FILE", path: "tls.cert_file", usage: "server certificate, enables TLS",
+		field: func(c *Config) interface{} { return &c.GRPC_TLS_CERT_FILE }},
+	{env: "GRPC_TLS_KEY_FILE", path: "tls.key_file", usage: "server private key",
+		field: func(c *Config) interface{} { return &c.GRPC_TLS_KEY_FILE }},
+	{env: "GRPC_TLS_CLIENT_CA_FILE", path: "tls.client_ca_file", usage: "CA for client certificates, enables mTLS",
+		field: func(c *Config) interface{} { return &c.GRPC_TLS_CLIENT_CA_FILE }},
+	{env: "GRPC_TLS_RELOAD_INTERVAL", path: "tls.reload_interval", def: "1m", usage: "check the certificate files for changes this often",
+		field: func(c *Config) interface{} { return &c.GRPC_TLS_RELOAD_INTERVAL }},
+
+	{env: "AUTH_TOKEN_SECRET", path: "auth.token_secret", secret: true, usage: "verifies the HS256 bearer tokens that identify callers",
+		field: func(c *Config) interface{} { return &c.AUTH_TOKEN_SECRET }},
+
+	{env: "DB_HOST", path: "database.host", def: "localhost", usage: "Postgres host",
+		field: func(c *Config) interface{} { return &c.DB_HOST }},
+	{env: "DB_PORT", path: "database.port", def: "5432", usage: "Postgres port",
+		field: func(c *Config) interface{} { return &c.DB_PORT }},
+	{env: "DB_USER", path: "database.user", def: "postgres", usage: "Postgres user",
+		field: func(c *Config) interface{} { return &c.DB_USER }},
+	{env: "DB_PASSWORD", path: "database.password", secret: true, usage: "Postgres password",
+		field: func(c *Config) interface{} { return &c.DB_PASSWORD }},
+	{env: "DB_NAME", path: "database.name", def: "pharmakartdb", usage: "Postgres database",
+		field: func(c *Config) interface{} { return &c.DB_NAME }},
+	{env: "DB_SSLMODE", path: "database.sslmode", def: "prefer", usage: "Postgres sslmode",
+		field: func(c *Config) interface{} { return &c.DB_SSLMODE }},
+	{env: "DB_MAX_OPEN_CONNS", path: "database.max_open_conns", def: "20", usage: "connections in the pool, 0 for no limit",
+		field: func(c *Config) interface{} { return &c.DB_MAX_OPEN_CONNS }},
+	{env: "DB_MAX_IDLE_CONNS", path: "database.max_idle_conns", def: "5", usage: "idle connections kept in the pool",
+		field: func(c *Config) interface{} { return &c.DB_MAX_IDLE_CONNS }},
+	{env: "DB_CONN_MAX_LIFETIME", path: "database.conn_max_lifetime", def: "30m", usage: "replace connections after this long, 0 to keep them",
+		field: func(c *Config) interface{} { return &c.DB_CONN_MAX_LIFETIME }},
+	{env: "DB_CONN_MAX_IDLE_TIME", path: "database.conn_max_idle_time", def: "5m", usage: "close connections idle this long, 0 to keep them",
+		field: func(c *Config) interface{} { return &c.DB_CONN_MAX_IDLE_TIME }},
+	{env: "DB_STATEMENT_TIMEOUT", path: "database.statement_timeout", def: "30s", usage: "cancel statements running longer, 0 for no limit or behind PgBouncer in transaction mode",
+		field: func(c *Config) interface{} { return &c.DB_STATEMENT_TIMEOUT }},
+	{env: "DB_CONNECT_TIMEOUT", path: "database.connect_timeout", def: "1m", usage: "keep retrying the first connection this long",
+		field: func(c *Config) interface{} { return &c.DB_CONNECT_TIMEOUT }},
+	{env: "DB_PREPARED_STATEMENTS", path: "database.prepared_statements", def: "true", usage: "cache prepared statements, disable behind PgBouncer in transaction mode",
+		field: func(c *Config) interface{} { return &c.DB_PREPARED_STATEMENTS }},
+	{env: "DB_REPLICA_HOSTS", path: "database.replica_hosts", usage: "comma-separated read replicas for list queries, host or host:port",
+		field: func(c *Config) interface{} { return &c.DB_REPLICA_HOSTS }},
+
+	{env: "AWS_REGION", path: "aws.region", def: "ca-central-1", usage: "AWS region",
+		field: func(c *Config) interface{} { return &c.AWS_REGION }},
+	{env: "AWS_ACCESS_KEY_ID", path: "aws.access_key_id", usage: "static AWS credentials, empty for the default chain",
+		field: func(c *Config) interface{} { return &c.AWS_ACCESS_KEY_ID }},
+	{env: "AWS_SECRET_ACCESS_KEY", path: "aws.secret_access_key", secret: true, usage: "static AWS credentials, empty for the default chain",
+		field: func(c *Config) interface{} { return &c.AWS_SECRET_ACCESS_KEY }},
+
+	{env: "DISPATCHER", path: "dispatcher.type", def: "log", usage: "where reminders are sent: log or sqs",
+		field: func(c *Config) interface{} { return &c.DISPATCHER }},
+	{env: "SQS_QUEUE_URL", path: "dispatcher.sqs_queue_url", usage: "queue for the sqs dispatcher",
+		field: func(c *Config) interface{} { return &c.SQS_QUEUE_URL }},
+	{env: "SNS_TOPIC_ARN", path: "dispatcher.sns_topic_arn", usage: "SNS topic",
+		field: func(c *Config) interface{} { return &c.SNS_TOPIC_ARN }},
+	{env: "DISPATCH_BATCH_SIZE", path: "dispatcher.batch_size", def: "500", usage: "reminders claimed per batch",
+		field: func(c *Config) interface{} { return &c.DISPATCH_BATCH_SIZE }},
+	{env: "DISPATCH_CONCURRENCY", path: "dispatcher.concurrency", def: "4", usage: "batches processed at once",
+		field: func(c *Config) interface{} { return &c.DISPATCH_CONCURRENCY }},
+	{env: "RATE_LIMIT_GLOBAL", path: "dispatcher.rate_limit_global", usage: "messages per period across all channels",
+		field: func(c *Config) interface{} { return &c.RATE_LIMIT_GLOBAL }},
+	{env: "RATE_LIMIT_CHANNEL", path: "dispatcher.rate_limit_channel", usage: "messages per period for each channel",
+		field: func(c *Config) interface{} { return &c.RATE_LIMIT_CHANNEL }},
+	{env: "RATE_LIMIT_CUSTOMER", path: "dispatcher.rate_limit_customer", usage: "messages per period for each customer",
+		field: func(c *Config) interface{} { return &c.RATE_LIMIT_CUSTOMER }},
+
+	{env: "OUTBOX_RELAY_SCHEDULE", path: "cron.outbox_relay_schedule", def: "@every 1m", usage: "cron schedule of the outbox relay",
+		field: func(c *Config) interface{} { return &c.OUTBOX_RELAY_SCHEDULE }},
+	{env: "RETRY_SCHEDULE", path: "cron.retry_schedule", def: "@every 5m", usage: "cron schedule of failed send retries",
+		field: func(c *Config) interface{} { return &c.RETRY_SCHEDULE }},
+	{env: "HEALTH_CHECK_INTERVAL", path: "cron.health_check_interval", def: "10s", usage: "how often health is checked",
+		field: func(c *Config) interface{} { return &c.HEALTH_CHECK_INTERVAL }},
+
+	{env: "RETRY_MAX_ATTEMPTS", path: "reminders.retry_max_attempts", def: "5", usage: "sends before a reminder is dead-lettered",
+		field: func(c *Config) interface{} { return &c.RETRY_MAX_ATTEMPTS }},
+	{env: "RETRY_BASE_DELAY", path: "reminders.retry_base_delay", def: "1m", usage: "backoff after the first failed send",
+		field: func(c *Config) interface{} { return &c.RETRY_BASE_DELAY }},
+	{env: "RETRY_MAX_DELAY", path: "reminders.retry_max_delay", def: "6h", usage: "longest backoff between sends",
+		field: func(c *Config) interface{} { return &c.RETRY_MAX_DELAY }},
+	{env: "REQUIRE_CONSENT", path: "reminders.require_consent", def: "true", usage: "only remind customers who opted in",
+		field: func(c *Config) interface{} { return &c.REQUIRE_CONSENT }},
+	{env: "UNSUBSCRIBE_SECRET", path: "reminders.unsubscribe_secret", secret: true, usage: "signs unsubscribe links",
+		field: func(c *Config) interface{} { return &c.UNSUBSCRIBE_SECRET }},
+	{env: "REMINDER_EVENTS_BUS", path: "reminders.events_bus", def: "memory", usage: "how reminder events reach watchers: memory or postgres",
+		field: func(c *Config) interface{} { return &c.REMINDER_EVENTS_BUS }},
+
+	{env: "EVENT_SOURCE", path: "events.source", usage: "where order events come from: sqs, webhook, memory or empty",
+		field: func(c *Config) interface{} { return &c.EVENT_SOURCE }},
+	{env: "ORDER_EVENTS_QUEUE_URL", path: "events.order_events_queue_url", usage: "queue for the sqs event source",
+		field: func(c *Config) interface{} { return &c.ORDER_EVENTS_QUEUE_URL }},
+	{env: "EVENTS_WEBHOOK_PORT", path: "events.webhook_port", def: "8081", usage: "port for the webhook event source",
+		field: func(c *Config) interface{} { return &c.EVENTS_WEBHOOK_PORT }},
+	{env: "EVENTS_WEBHOOK_SECRET", path: "events.webhook_secret", secret: true, usage: "verifies webhook signatures",
+		field: func(c *Config) interface{} { return &c.EVENTS_WEBHOOK_SECRET }},
+
+	{env: "LOG_LEVEL", path: "logging.level", def: "info", usage: "debug, info, warn or error",
+		field: func(c *Config) interface{} { return &c.LOG_LEVEL }},
+	{env: "LOG_FORMAT", path: "logging.format", def: "json", usage: "json, pretty or text",
+		field: func(c *Config) interface{} { return &c.LOG_FORMAT }},
+
+	{env: "TRACING_EXPORTER", path: "tracing.exporter", usage: "otlp, stdout or empty to disable",
+		field: func(c *Config) interface{} { return &c.TRACING_EXPORTER }},
+	{env: "TRACING_OTLP_ENDPOINT", path: "tracing.otlp_endpoint", usage: "OTLP collector address",
+		field: func(c *Config) interface{} { return &c.TRACING_OTLP_ENDPOINT }},
+	{env: "TRACING_OTLP_INSECURE", path: "tracing.otlp_insecure", def: "false", usage: "export to the collector without TLS",
+		field: func(c *Config) interface{} { return &c.TRACING_OTLP_INSECURE }},
+
+	{env: "ENCRYPTION_KEYS", path: "encryption.keys", secret: true, usage: "comma-separated id:base64 keys",
+		field: func(c *Config) interface{} { return &c.ENCRYPTION_KEYS }},
+	{env: "ENCRYPTION_KEY_ID", path: "encryption.key_id", usage: "key that wraps new data keys",
+		field: func(c *Config) interface{} { return &c.ENCRYPTION_KEY_ID }},
+}
+
+// flagName returns the command-line flag for the setting
+func (s setting) flagName() string {
+	return strings.ReplaceAll(strings.ToLower(s.env), "_", "-")
+}
+
+// set parses value into the setting's field
+func (s setting) set(c *Config, value string) error {
+	switch field := s.field(c).(type) {
+	case *string:
+		*field = value
+	case *int:
+		n, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("%q is not an integer", value)
+		}
+		*field = n
+	case *bool:
+		b, err := strconv.ParseBool(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a boolean", value)
+		}
+		*field = b
+	case *time.Duration:
+		d, err := time.ParseDuration(value)
+		if err != nil {
+			return fmt.Errorf("%q is not a duration such as 30s or 5m", value)
+		}
+		*field = d
+	default:
+		return fmt.Errorf("unsupported type %T", field)
+	}
+	return nil
+}
+
+// get returns the setting's value as YAML would hold it
+func (s setting) get(c *Config) interface{} {
+	switch field := s.field(c).(type) {
+	case *string:
+		return *field
+	case *int:
+		return *field
+	case *bool:
+		return *field
+	case *time.Duration:
+		return field.String()
+	default:
+		return nil
+	}
+}
@@ -0,0 +1,185 @@
+package config
+
+import (
+	"flag"
+	"fmt"
+	"os"
+	"sort"
+	"strings"
+
+	"gopkg.in/yaml.v3"
+)
+
+// value is a raw setting and where it came from
+type value struct {
+	value  string
+	source string
+}
+
+// secretFileSuffix names the variant of a secret setting that holds the path
+// of a file to read it from, e.g. DB_PASSWORD_FILE or database.password_file
+const secretFileSuffix = "_file"
+
+// readSecret reads a secret from path, dropping the trailing newline most
+// editors and secret mounts add
+func readSecret(path string) (string, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return "", err
+	}
+	return strings.TrimRight(string(data), "\r\n"), nil
+}
+
+// lookup adds the setting from one source, whether given directly or through
+// a file. Setting both in the same source is an error.
+func lookup(values map[string]value, s setting, direct, file *string, source, fileSource string) error {
+	if direct != nil && file != nil {
+		return fmt.Errorf("%s and %s are both set", source, fileSource)
+	}
+
+	if direct != nil {
+		values[s.env] = value{value: *direct, source: source}
+	}
+	if file != nil {
+		secret, err := readSecret(*file)
+		if err != nil {
+			return fmt.Errorf("%s: %w", fileSource, err)
+		}
+		values[s.env] = value{value: secret, source: fileSource}
+	}
+	return nil
+}
+
+// envValues reads the settings set in the environment
+func envValues() (map[string]value, error) {
+	values := make(map[string]value)
+
+	for _, s := range settings {
+		// A variable set to an empty string is a value, e.g. METRICS_PORT=
+		// disables the metrics server
+		var direct, file *string
+		if v, ok := os.LookupEnv(s.env); ok {
+			direct = &v
+		}
+
+		// An empty path names no file
+		fileEnv := s.env + strings.ToUpper(secretFileSuffix)
+		if v, ok := os.LookupEnv(fileEnv); ok && v != "" && s.secret {
+			file = &v
+		}
+
+		if err := lookup(values, s, direct, file, "env "+s.env, "env "+fileEnv); err != nil {
+			return nil, err
+		}
+	}
+	return values, nil
+}
+
+// fileValues reads the settings in the YAML config file at path, if any.
+// Unknown sections and keys are rejected so typos do not go unnoticed.
+func fileValues(path string) (map[string]value, error) {
+	values := make(map[string]value)
+	if path == "" {
+		return values, nil
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, fmt.Errorf("config file: %w", err)
+	}
+
+	var doc map[string]map[string]interface{}
+	if err := yaml.Unmarshal(data, &doc); err != nil {
+		return nil, fmt.Errorf("config file %s: %w", path, err)
+	}
+
+	keys := make(map[string]string)
+	for section, entries := range doc {
+		for key, raw := range entries {
+			switch raw.(type) {
+			case map[string]interface{}, []interface{}:
+				return nil, fmt.Errorf("config file %s: %s.%s must be a single value", path, section, key)
+			}
+			if raw == nil {
+				continue
+			}
+			keys[section+"."+key] = fmt.Sprint(raw)
+		}
+	}
+
+	known := make(map[string]bool)
+	for _, s := range settings {
+		var direct, file *string
+		if v, ok := keys[s.path]; ok {
+			direct = &v
+		}
+		known[s.path] = true
+
+		if s.secret {
+			filePath := s.path + secretFileSuffix
+			if v, ok := keys[filePath]; ok {
+				file = &v
+			}
+			known[filePath] = true
+		}
+
+		if err := lookup(values, s, direct, file, "config "+s.path, "config "+s.path+secretFileSuffix); err != nil {
+			return nil, err
+		}
+	}
+
+	var unknown []string
+	for key := range keys {
+		if !known[key] {
+			unknown = append(unknown, key)
+		}
+	}
+	if len(unknown) > 0 {
+		sort.Strings(unknown)
+		return nil, fmt.Errorf("config file %s: unknown settings %s", path, strings.Join(unknown, ", "))
+	}
+	return values, nil
+}
+
+// parseFlags reads the settings passed as flags, and the --config file
+func parseFlags(args []string) (map[string]value, string, error) {
+	fs := flag.NewFlagSet("reminder-svc", flag.ContinueOnError)
+	configFile := fs.String("config", "", "YAML config file, overrides CONFIG_FILE")
+
+	direct := make(map[string]*string)
+	files := make(map[string]*string)
+	for _, s := range settings {
+		direct[s.env] = fs.String(s.flagName(), "", fmt.Sprintf("%s (%s)", s.usage, s.env))
+		if s.secret {
+			files[s.env] = fs.String(s.flagName()+"-file", "", fmt.Sprintf("read %s from a file", s.env))
+		}
+	}
+
+	if err := fs.Parse(args); err != nil {
+		return nil, "", err
+	}
+	if fs.NArg() > 0 {
+		return nil, "", fmt.Errorf("unexpected arguments: %s", strings.Join(fs.Args(), " "))
+	}
+
+	set := make(map[string]bool)
+	fs.Visit(func(f *flag.Flag) {
+		set[f.Name] = true
+	})
+
+	values := make(map[string]value)
+	for _, s := range settings {
+		var d, f *string
+		if set[s.flagName()] {
+			d = direct[s.env]
+		}
+		if set[s.flagName()+"-file"] {
+			f = files[s.env]
+		}
+
+		if err := lookup(values, s, d, f, "flag --"+s.flagName(), "flag --"+s.flagName()+"-file"); err != nil {
+			return nil, "", err
+		}
+	}
+	return values, *configFile, nil
+}
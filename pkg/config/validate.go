@@ -0,0 +1,168 @@
+package config
+
+import (
+	"fmt"
+	"net"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/robfig/cron/v3"
+)
+
+// validate checks the settings, returning one error per problem
+func (c *Config) validate() []error {
+	var errs []error
+	fail := func(format string, args ...interface{}) {
+		errs = append(errs, fmt.Errorf(format, args...))
+	}
+
+	oneOf := func(env, value string, allowed ...string) {
+		for _, a := range allowed {
+			if value == a {
+				return
+			}
+		}
+		fail("%s must be one of %s, got %q", env, strings.Join(quoted(allowed), ", "), value)
+	}
+	required := func(env, value, reason string) {
+		if value == "" {
+			fail("%s", strings.TrimSpace(env+" is required "+reason))
+		}
+	}
+	port := func(env, value string, optional bool) {
+		if value == "" && optional {
+			return
+		}
+		if n, err := strconv.Atoi(value); err != nil || n < 1 || n > 65535 {
+			fail("%s must be a port between 1 and 65535, got %q", env, value)
+		}
+	}
+	positive := func(env string, value int64) {
+		if value <= 0 {
+			fail("%s must be positive", env)
+		}
+	}
+	notNegative := func(env string, value int64) {
+		if value < 0 {
+			fail("%s must not be negative", env)
+		}
+	}
+	schedule := func(env, value string) {
+		if _, err := cron.ParseStandard(value); err != nil {
+			fail("%s is not a valid cron schedule: %v", env, err)
+		}
+	}
+
+	// Server
+	port("PORT", c.Port, false)
+	port("GATEWAY_PORT", c.GATEWAY_PORT, true)
+	positive("GATEWAY_WATCH_CONNS", int64(c.GATEWAY_WATCH_CONNS))
+	port("METRICS_PORT", c.METRICS_PORT, true)
+	positive("RPC_TIMEOUT", int64(c.RPC_TIMEOUT))
+	positive("SHUTDOWN_TIMEOUT", int64(c.SHUTDOWN_TIMEOUT))
+	positive("GRPC_KEEPALIVE_TIME", int64(c.GRPC_KEEPALIVE_TIME))
+	positive("GRPC_KEEPALIVE_TIMEOUT", int64(c.GRPC_KEEPALIVE_TIMEOUT))
+	positive("GRPC_KEEPALIVE_MIN_TIME", int64(c.GRPC_KEEPALIVE_MIN_TIME))
+	notNegative("GRPC_MAX_CONCURRENT_STREAMS", int64(c.GRPC_MAX_CONCURRENT_STREAMS))
+	positive("GRPC_MAX_RECV_MSG_SIZE", int64(c.GRPC_MAX_RECV_MSG_SIZE))
+	positive("GRPC_MAX_SEND_MSG_SIZE", int64(c.GRPC_MAX_SEND_MSG_SIZE))
+
+	// TLS
+	if (c.GRPC_TLS_CERT_FILE == "") != (c.GRPC_TLS_KEY_FILE == "") {
+		fail("GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE must be set together")
+	}
+	if c.GRPC_TLS_CLIENT_CA_FILE != "" && c.GRPC_TLS_CERT_FILE == "" {
+		fail("GRPC_TLS_CLIENT_CA_FILE requires GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE")
+	}
+	if c.GRPC_TLS_CERT_FILE != "" {
+		positive("GRPC_TLS_RELOAD_INTERVAL", int64(c.GRPC_TLS_RELOAD_INTERVAL))
+	}
+
+	// Database
+	required("DB_HOST", c.DB_HOST, "")
+	port("DB_PORT", c.DB_PORT, false)
+	required("DB_USER", c.DB_USER, "")
+	required("DB_PASSWORD", c.DB_PASSWORD, "(or DB_PASSWORD_FILE)")
+	required("DB_NAME", c.DB_NAME, "")
+	oneOf("DB_SSLMODE", c.DB_SSLMODE, "disable", "allow", "prefer", "require", "verify-ca", "verify-full")
+	notNegative("DB_MAX_OPEN_CONNS", int64(c.DB_MAX_OPEN_CONNS))
+	notNegative("DB_MAX_IDLE_CONNS", int64(c.DB_MAX_IDLE_CONNS))
+	notNegative("DB_CONN_MAX_LIFETIME", int64(c.DB_CONN_MAX_LIFETIME))
+	notNegative("DB_CONN_MAX_IDLE_TIME", int64(c.DB_CONN_MAX_IDLE_TIME))
+	notNegative("DB_STATEMENT_TIMEOUT", int64(c.DB_STATEMENT_TIMEOUT))
+	if c.DB_STATEMENT_TIMEOUT > 0 && c.DB_STATEMENT_TIMEOUT < time.Millisecond {
+		fail("DB_STATEMENT_TIMEOUT must be at least 1ms")
+	}
+	positive("DB_CONNECT_TIMEOUT", int64(c.DB_CONNECT_TIMEOUT))
+	for _, host := range c.replicaHosts() {
+		if _, replicaPort, err := net.SplitHostPort(host); err != nil {
+			fail("DB_REPLICA_HOSTS entry %q is not a host or host:port", host)
+		} else {
+			port("DB_REPLICA_HOSTS port", replicaPort, false)
+		}
+	}
+	if c.DB_MAX_OPEN_CONNS > 0 && c.DB_MAX_IDLE_CONNS > c.DB_MAX_OPEN_CONNS {
+		fail("DB_MAX_IDLE_CONNS (%d) must not exceed DB_MAX_OPEN_CONNS (%d)", c.DB_MAX_IDLE_CONNS, c.DB_MAX_OPEN_CONNS)
+	}
+
+	// AWS
+	if (c.AWS_ACCESS_KEY_ID == "") != (c.AWS_SECRET_ACCESS_KEY == "") {
+		fail("AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set together")
+	}
+	if c.DISPATCHER == "sqs" || c.EVENT_SOURCE == "sqs" {
+		required("AWS_REGION", c.AWS_REGION, "for SQS")
+	}
+
+	// Dispatcher
+	oneOf("DISPATCHER", c.DISPATCHER, "log", "sqs")
+	if c.DISPATCHER == "sqs" {
+		required("SQS_QUEUE_URL", c.SQS_QUEUE_URL, "when DISPATCHER is sqs")
+	}
+	positive("DISPATCH_BATCH_SIZE", int64(c.DISPATCH_BATCH_SIZE))
+	positive("DISPATCH_CONCURRENCY", int64(c.DISPATCH_CONCURRENCY))
+
+	// Cron
+	schedule("OUTBOX_RELAY_SCHEDULE", c.OUTBOX_RELAY_SCHEDULE)
+	schedule("RETRY_SCHEDULE", c.RETRY_SCHEDULE)
+	positive("HEALTH_CHECK_INTERVAL", int64(c.HEALTH_CHECK_INTERVAL))
+
+	// Reminders
+	positive("RETRY_MAX_ATTEMPTS", int64(c.RETRY_MAX_ATTEMPTS))
+	positive("RETRY_BASE_DELAY", int64(c.RETRY_BASE_DELAY))
+	if c.RETRY_MAX_DELAY < c.RETRY_BASE_DELAY {
+		fail("RETRY_MAX_DELAY (%s) must not be shorter than RETRY_BASE_DELAY (%s)", c.RETRY_MAX_DELAY, c.RETRY_BASE_DELAY)
+	}
+	required("UNSUBSCRIBE_SECRET", c.UNSUBSCRIBE_SECRET, "(or UNSUBSCRIBE_SECRET_FILE) to sign unsubscribe links")
+	oneOf("REMINDER_EVENTS_BUS", c.REMINDER_EVENTS_BUS, "memory", "postgres")
+
+	// Order events
+	oneOf("EVENT_SOURCE", c.EVENT_SOURCE, "", "sqs", "webhook", "memory")
+	switch c.EVENT_SOURCE {
+	case "sqs":
+		required("ORDER_EVENTS_QUEUE_URL", c.ORDER_EVENTS_QUEUE_URL, "when EVENT_SOURCE is sqs")
+	case "webhook":
+		port("EVENTS_WEBHOOK_PORT", c.EVENTS_WEBHOOK_PORT, false)
+		required("EVENTS_WEBHOOK_SECRET", c.EVENTS_WEBHOOK_SECRET, "(or EVENTS_WEBHOOK_SECRET_FILE) when EVENT_SOURCE is webhook")
+	}
+
+	// Logging and tracing
+	oneOf("LOG_LEVEL", strings.ToLower(c.LOG_LEVEL), "trace", "debug", "info", "warn", "warning", "error", "fatal", "panic")
+	oneOf("LOG_FORMAT", strings.ToLower(c.LOG_FORMAT), "json", "pretty", "text")
+	oneOf("TRACING_EXPORTER", c.TRACING_EXPORTER, "", "otlp", "stdout")
+
+	// Encryption
+	if c.ENCRYPTION_KEYS != "" {
+		required("ENCRYPTION_KEY_ID", c.ENCRYPTION_KEY_ID, "when ENCRYPTION_KEYS is set")
+	}
+
+	return errs
+}
+
+func quoted(values []string) []string {
+	out := make([]string, len(values))
+	for i, v := range values {
+		out[i] = strconv.Quote(v)
+	}
+	return out
+}
@@ -0,0 +1,82 @@
+package config
+
+import (
+	"strings"
+	"testing"
+	"time"
+)
+
+// validConfig loads the defaults with the required settings set
+func validConfig(t *testing.T) *Config {
+	t.Helper()
+	clearEnv(t)
+	setRequired(t)
+
+	cfg, err := Load(nil)
+	if err != nil {
+		t.Fatalf("Load() error = %v", err)
+	}
+	return cfg
+}
+
+func TestValidate(t *testing.T) {
+	tests := []struct {
+		name    string
+		change  func(c *Config)
+		wantErr string
+	}{
+		{name: "defaults", change: func(c *Config) {}},
+		{name: "invalid port", change: func(c *Config) { c.Port = "70000" }, wantErr: `PORT must be a port between 1 and 65535, got "70000"`},
+		{name: "optional port unset", change: func(c *Config) { c.GATEWAY_PORT = "" }},
+		{name: "invalid optional port", change: func(c *Config) { c.METRICS_PORT = "metrics" }, wantErr: `METRICS_PORT must be a port between 1 and 65535, got "metrics"`},
+		{name: "watch conns", change: func(c *Config) { c.GATEWAY_WATCH_CONNS = 0 }, wantErr: "GATEWAY_WATCH_CONNS must be positive"},
+		{name: "tls cert without key", change: func(c *Config) { c.GRPC_TLS_CERT_FILE = "server.crt" }, wantErr: "GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE must be set together"},
+		{name: "client ca without cert", change: func(c *Config) { c.GRPC_TLS_CLIENT_CA_FILE = "ca.crt" }, wantErr: "GRPC_TLS_CLIENT_CA_FILE requires GRPC_TLS_CERT_FILE and GRPC_TLS_KEY_FILE"},
+		{name: "sslmode", change: func(c *Config) { c.DB_SSLMODE = "on" }, wantErr: `DB_SSLMODE must be one of "disable", "allow", "prefer", "require", "verify-ca", "verify-full", got "on"`},
+		{name: "negative pool size", change: func(c *Config) { c.DB_MAX_OPEN_CONNS = -1 }, wantErr: "DB_MAX_OPEN_CONNS must not be negative"},
+		{name: "idle above open", change: func(c *Config) { c.DB_MAX_OPEN_CONNS, c.DB_MAX_IDLE_CONNS = 5, 10 }, wantErr: "DB_MAX_IDLE_CONNS (10) must not exceed DB_MAX_OPEN_CONNS (5)"},
+		{name: "idle with unlimited open", change: func(c *Config) { c.DB_MAX_OPEN_CONNS, c.DB_MAX_IDLE_CONNS = 0, 10 }},
+		{name: "no statement timeout", change: func(c *Config) { c.DB_STATEMENT_TIMEOUT = 0 }},
+		{name: "negative statement timeout", change: func(c *Config) { c.DB_STATEMENT_TIMEOUT = -time.Second }, wantErr: "DB_STATEMENT_TIMEOUT must not be negative"},
+		{name: "statement timeout under 1ms", change: func(c *Config) { c.DB_STATEMENT_TIMEOUT = time.Microsecond }, wantErr: "DB_STATEMENT_TIMEOUT must be at least 1ms"},
+		{name: "connect timeout", change: func(c *Config) { c.DB_CONNECT_TIMEOUT = 0 }, wantErr: "DB_CONNECT_TIMEOUT must be positive"},
+		{name: "replica hosts", change: func(c *Config) { c.DB_REPLICA_HOSTS = "replica-1,replica-2:5433" }},
+		{name: "replica port", change: func(c *Config) { c.DB_REPLICA_HOSTS = "replica-1:postgres" }, wantErr: `DB_REPLICA_HOSTS port must be a port between 1 and 65535, got "postgres"`},
+		{name: "replica host", change: func(c *Config) { c.DB_REPLICA_HOSTS = "replica-1:5432:5433" }, wantErr: `DB_REPLICA_HOSTS entry "replica-1:5432:5433" is not a host or host:port`},
+		{name: "aws credentials", change: func(c *Config) { c.AWS_ACCESS_KEY_ID = "key" }, wantErr: "AWS_ACCESS_KEY_ID and AWS_SECRET_ACCESS_KEY must be set together"},
+		{name: "sqs needs region", change: func(c *Config) { c.DISPATCHER, c.SQS_QUEUE_URL, c.AWS_REGION = "sqs", "queue", "" }, wantErr: "AWS_REGION is required for SQS"},
+		{name: "dispatcher", change: func(c *Config) { c.DISPATCHER = "smtp" }, wantErr: `DISPATCHER must be one of "log", "sqs", got "smtp"`},
+		{name: "cron schedule", change: func(c *Config) { c.RETRY_SCHEDULE = "every minute" }, wantErr: "RETRY_SCHEDULE is not a valid cron schedule"},
+		{name: "retry delays", change: func(c *Config) { c.RETRY_BASE_DELAY, c.RETRY_MAX_DELAY = time.Hour, time.Minute }, wantErr: "RETRY_MAX_DELAY (1m0s) must not be shorter than RETRY_BASE_DELAY (1h0m0s)"},
+		{name: "webhook", change: func(c *Config) { c.EVENT_SOURCE, c.EVENTS_WEBHOOK_SECRET = "webhook", "webhook-secret" }},
+		{
+			name: "webhook port",
+			change: func(c *Config) {
+				c.EVENT_SOURCE, c.EVENTS_WEBHOOK_SECRET = "webhook", "webhook-secret"
+				c.EVENTS_WEBHOOK_PORT = ""
+			},
+			wantErr: `EVENTS_WEBHOOK_PORT must be a port between 1 and 65535, got ""`,
+		},
+		{name: "webhook secret", change: func(c *Config) { c.EVENT_SOURCE = "webhook" }, wantErr: "EVENTS_WEBHOOK_SECRET is required (or EVENTS_WEBHOOK_SECRET_FILE) when EVENT_SOURCE is webhook"},
+		{name: "log level is case insensitive", change: func(c *Config) { c.LOG_LEVEL = "DEBUG" }},
+		{name: "log format", change: func(c *Config) { c.LOG_FORMAT = "xml" }, wantErr: `LOG_FORMAT must be one of "json", "pretty", "text", got "xml"`},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			cfg := validConfig(t)
+			tt.change(cfg)
+
+			errs := cfg.validate()
+			if tt.wantErr == "" {
+				if len(errs) != 0 {
+					t.Fatalf("validate() = %v, want no errors", errs)
+				}
+				return
+			}
+			if len(errs) != 1 || !strings.Contains(errs[0].Error(), tt.wantErr) {
+				t.Fatalf("validate() = %v, want %q", errs, tt.wantErr)
+			}
+		})
+	}
+}
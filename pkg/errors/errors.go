@@ -4,6 +4,8 @@ package errors
 import (
 	"errors"
 	"net/http"
+
+	"github.com/PharmaKart/reminder-svc/pkg/redact"
 )
 
 // ErrorType represents the type of an error
@@ -97,7 +99,7 @@ func NewInternalError(err error) *AppError {
 	return &AppError{
 		Type:    InternalError,
 		Message: "An internal error occurred",
-		Details: map[string]string{"internal": err.Error()},
+		Details: map[string]string{"internal": redact.Text(err.Error())},
 		Status:  http.StatusInternalServerError,
 	}
 }
@@ -0,0 +1,81 @@
+// Package redact masks customer contact details before they are logged or
+// returned in error details.
+package redact
+
+import (
+	"regexp"
+	"strings"
+)
+
+var (
+	emailPattern = regexp.MustCompile(`[A-Za-z0-9._%+\-]+@[A-Za-z0-9.\-]+\.[A-Za-z]{2,}`)
+	// Ten digit numbers with an optional country code and common separators.
+	// The leading group keeps the digits of IDs such as UUIDs from matching.
+	phonePattern = regexp.MustCompile(`(^|[^\w\-])(\+?\d{1,3}[\s\-.]?)?\(?\d{3}\)?[\s\-.]?\d{3}[\s\-.]?\d{4}\b`)
+)
+
+// sensitiveFields are log and error detail keys whose values are always masked
+var sensitiveFields = map[string]func(string) string{
+	"email":   Email,
+	"phone":   Phone,
+	"address": Address,
+}
+
+// Email keeps the first character of the local part and the domain, so
+// "jane.doe@example.com" becomes "j***@example.com"
+func Email(email string) string {
+	if email == "" {
+		return ""
+	}
+
+	at := strings.LastIndex(email, "@")
+	if at <= 0 {
+		return "***"
+	}
+	return email[:1] + "***" + email[at:]
+}
+
+// Phone keeps the last four digits, so "+14165550123" becomes "***0123"
+func Phone(phone string) string {
+	if phone == "" {
+		return ""
+	}
+
+	if len(phone) <= 4 {
+		return "***"
+	}
+	return "***" + phone[len(phone)-4:]
+}
+
+// Address masks an email address or phone number
+func Address(address string) string {
+	if strings.Contains(address, "@") {
+		return Email(address)
+	}
+	return Phone(address)
+}
+
+// Text masks every email address and phone number found in free text, such
+// as error messages and provider responses
+func Text(text string) string {
+	text = emailPattern.ReplaceAllStringFunc(text, Email)
+
+	var b strings.Builder
+	last := 0
+	for _, loc := range phonePattern.FindAllStringSubmatchIndex(text, -1) {
+		// loc[3] ends the leading group, which is kept as is
+		b.WriteString(text[last:loc[3]])
+		b.WriteString(Phone(text[loc[3]:loc[1]]))
+		last = loc[1]
+	}
+	b.WriteString(text[last:])
+	return b.String()
+}
+
+// Field masks value if key names a contact detail and scrubs it otherwise
+func Field(key, value string) string {
+	if mask, ok := sensitiveFields[strings.ToLower(key)]; ok {
+		return mask(value)
+	}
+	return Text(value)
+}
@@ -0,0 +1,22 @@
+package utils
+
+import (
+	"context"
+
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+	"github.com/aws/aws-sdk-go-v2/aws"
+	awsconfig "github.com/aws/aws-sdk-go-v2/config"
+	"github.com/aws/aws-sdk-go-v2/credentials"
+)
+
+// LoadAWSConfig builds an AWS SDK configuration from the service configuration.
+// Without static keys the default credential chain is used, e.g. an IAM role.
+func LoadAWSConfig(ctx context.Context, cfg *config.Config) (aws.Config, error) {
+	opts := []func(*awsconfig.LoadOptions) error{
+		awsconfig.WithRegion(cfg.AWS_REGION),
+	}
+	if cfg.AWS_ACCESS_KEY_ID != "" {
+		opts = append(opts, awsconfig.WithCredentialsProvider(credentials.NewStaticCredentialsProvider(cfg.AWS_ACCESS_KEY_ID, cfg.AWS_SECRET_ACCESS_KEY, "")))
+	}
+	return awsconfig.LoadDefaultConfig(ctx, opts...)
+}
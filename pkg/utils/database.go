@@ -1,16 +1,277 @@
 package utils
 
 import (
+	"context"
+	"database/sql"
+	"database/sql/driver"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"strconv"
+	"sync"
+	"time"
+
 	"github.com/PharmaKart/reminder-svc/pkg/config"
+	"github.com/jackc/pgx/v5"
+	"github.com/jackc/pgx/v5/pgconn"
+	"github.com/jackc/pgx/v5/stdlib"
 	"gorm.io/driver/postgres"
 	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+	"gorm.io/plugin/dbresolver"
 )
 
-// ConnectDB connects to the database
-func ConnectDB(cfg *config.Config) (*gorm.DB, error) {
-	db, err := gorm.Open(postgres.Open(cfg.DBConnString), &gorm.Config{})
+// replicaResolver names the read replicas. Queries only use them when asked
+// to with ReadReplica, everything else stays on the primary.
+const replicaResolver = "read_replica"
+
+// Backoff between attempts to reach the primary at startup
+const (
+	connectRetryMin = time.Second
+	connectRetryMax = 30 * time.Second
+)
+
+// replicaRetryAfter is how long reads skip a replica after a connection error
+const replicaRetryAfter = 30 * time.Second
+
+// ConnectDB connects to the database, retrying with backoff for up to
+// DB_CONNECT_TIMEOUT so the service can start before Postgres is ready.
+// Reads from a replica that cannot be reached fall back to the primary.
+func ConnectDB(ctx context.Context, cfg *config.Config) (*gorm.DB, error) {
+	primary, err := openPool(cfg, cfg.DBConnString)
+	if err != nil {
+		return nil, err
+	}
+
+	db, err := connectPrimary(ctx, cfg, primary)
 	if err != nil {
+		primary.Close()
+		return nil, err
+	}
+
+	if err := useReplicas(db, cfg, primary); err != nil {
 		return nil, err
 	}
 	return db, nil
 }
+
+// ReadReplica routes a read query to a replica, when any are configured. Only
+// use it for listings that can tolerate replication lag.
+func ReadReplica() clause.Expression {
+	return dbresolver.Use(replicaResolver)
+}
+
+// openPool opens a connection pool to dsn. Connections are made lazily, so
+// this does not fail when the server is down.
+func openPool(cfg *config.Config, dsn string) (*sql.DB, error) {
+	connConfig, err := pgx.ParseConfig(dsn)
+	if err != nil {
+		return nil, err
+	}
+	if !cfg.DB_PREPARED_STATEMENTS {
+		// Without prepared statements, use the simple protocol so PgBouncer
+		// in transaction mode does not see statements from another session
+		connConfig.DefaultQueryExecMode = pgx.QueryExecModeSimpleProtocol
+	}
+
+	var opts []stdlib.OptionOpenDB
+	if cfg.DB_STATEMENT_TIMEOUT > 0 {
+		// Set once a connection opens rather than as a startup parameter,
+		// which PgBouncer rejects
+		setTimeout := "SET statement_timeout = " + strconv.FormatInt(cfg.DB_STATEMENT_TIMEOUT.Milliseconds(), 10)
+		opts = append(opts, stdlib.OptionAfterConnect(func(ctx context.Context, conn *pgx.Conn) error {
+			_, err := conn.Exec(ctx, setTimeout)
+			return err
+		}))
+	}
+
+	pool := stdlib.OpenDB(*connConfig, opts...)
+	pool.SetMaxOpenConns(cfg.DB_MAX_OPEN_CONNS)
+	pool.SetMaxIdleConns(cfg.DB_MAX_IDLE_CONNS)
+	pool.SetConnMaxLifetime(cfg.DB_CONN_MAX_LIFETIME)
+	pool.SetConnMaxIdleTime(cfg.DB_CONN_MAX_IDLE_TIME)
+	return pool, nil
+}
+
+// connectPrimary opens GORM on the primary's pool, which pings it, until the
+// primary answers
+func connectPrimary(ctx context.Context, cfg *config.Config, primary *sql.DB) (*gorm.DB, error) {
+	ctx, cancel := context.WithTimeout(ctx, cfg.DB_CONNECT_TIMEOUT)
+	defer cancel()
+
+	delay := connectRetryMin
+	for attempt := 1; ; attempt++ {
+		db, err := gorm.Open(postgres.New(postgres.Config{Conn: primary}), &gorm.Config{})
+		if err == nil {
+			return db, nil
+		}
+
+		Warn("Database not ready, retrying", map[string]interface{}{
+			"attempt": attempt,
+			"retry":   delay.String(),
+			"error":   err,
+		})
+		select {
+		case <-ctx.Done():
+			return nil, fmt.Errorf("database not reachable after %d attempts: %w", attempt, err)
+		case <-time.After(delay):
+		}
+		delay = min(delay*2, connectRetryMax)
+	}
+}
+
+// useReplicas registers the replicas with the replicaResolver. They are not
+// checked here: a replica that is down is found on its first read, which then
+// goes to the primary.
+func useReplicas(db *gorm.DB, cfg *config.Config, primary *sql.DB) error {
+	var replicas []gorm.Dialector
+	for i, dsn := range cfg.DBReplicaConnStrings {
+		pool, err := openPool(cfg, dsn)
+		if err != nil {
+			return fmt.Errorf("read replica %d: %w", i, err)
+		}
+		replicas = append(replicas, postgres.New(postgres.Config{
+			Conn: &replicaPool{replica: i, pool: pool, primary: primary},
+		}))
+	}
+	if len(replicas) == 0 {
+		return nil
+	}
+
+	if err := db.Use(dbresolver.Register(dbresolver.Config{Replicas: replicas}, replicaResolver)); err != nil {
+		return fmt.Errorf("read replicas: %w", err)
+	}
+
+	Info("Routing list queries to read replicas", map[string]interface{}{
+		"replicas": len(replicas),
+	})
+	return nil
+}
+
+// replicaPool sends queries to a replica and, when the replica cannot be
+// reached, to the primary instead. After a connection error the replica is
+// skipped for replicaRetryAfter and then tried again.
+type replicaPool struct {
+	replica int
+	pool    *sql.DB
+	primary *sql.DB
+
+	mu        sync.Mutex
+	downUntil time.Time
+}
+
+func (p *replicaPool) PrepareContext(ctx context.Context, query string) (*sql.Stmt, error) {
+	if p.up() {
+		stmt, err := p.pool.PrepareContext(ctx, query)
+		if !p.failed(ctx, err) {
+			return stmt, err
+		}
+	}
+	return p.primary.PrepareContext(ctx, query)
+}
+
+func (p *replicaPool) ExecContext(ctx context.Context, query string, args ...interface{}) (sql.Result, error) {
+	if p.up() {
+		result, err := p.pool.ExecContext(ctx, query, args...)
+		if !p.failed(ctx, err) {
+			return result, err
+		}
+	}
+	return p.primary.ExecContext(ctx, query, args...)
+}
+
+func (p *replicaPool) QueryContext(ctx context.Context, query string, args ...interface{}) (*sql.Rows, error) {
+	if p.up() {
+		rows, err := p.pool.QueryContext(ctx, query, args...)
+		if !p.failed(ctx, err) {
+			return rows, err
+		}
+	}
+	return p.primary.QueryContext(ctx, query, args...)
+}
+
+func (p *replicaPool) QueryRowContext(ctx context.Context, query string, args ...interface{}) *sql.Row {
+	if p.up() {
+		row := p.pool.QueryRowContext(ctx, query, args...)
+		if !p.failed(ctx, row.Err()) {
+			return row
+		}
+	}
+	return p.primary.QueryRowContext(ctx, query, args...)
+}
+
+// up reports whether the replica should be tried
+func (p *replicaPool) up() bool {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return time.Now().After(p.downUntil)
+}
+
+// failed reports whether err means the replica could not be reached, and if
+// so marks it down
+func (p *replicaPool) failed(ctx context.Context, err error) bool {
+	if err == nil || ctx.Err() != nil || !isConnectionError(err) {
+		return false
+	}
+
+	p.mu.Lock()
+	p.downUntil = time.Now().Add(replicaRetryAfter)
+	p.mu.Unlock()
+
+	Warn("Read replica not reachable, using the primary", map[string]interface{}{
+		"replica": p.replica,
+		"retry":   replicaRetryAfter.String(),
+		"error":   err,
+	})
+	return true
+}
+
+func isConnectionError(err error) bool {
+	var connectErr *pgconn.ConnectError
+	var netErr net.Error
+	return errors.Is(err, driver.ErrBadConn) ||
+		errors.Is(err, sql.ErrConnDone) ||
+		errors.Is(err, io.ErrUnexpectedEOF) ||
+		errors.As(err, &connectErr) ||
+		errors.As(err, &netErr) ||
+		pgconn.SafeToRetry(err)
+}
+
+// QueryCallback is called with a statement and its operation: create, query,
+// update, delete, row or raw
+type QueryCallback func(db *gorm.DB, operation string)
+
+// RegisterQueryCallbacks registers before and after around every GORM
+// operation on db, named prefix:before_<operation> and prefix:after_<operation>
+func RegisterQueryCallbacks(db *gorm.DB, prefix string, before, after QueryCallback) error {
+	callbacks := db.Callback()
+
+	processors := []struct {
+		operation string
+		before    func(name string, fn func(*gorm.DB)) error
+		after     func(name string, fn func(*gorm.DB)) error
+	}{
+		{"create", callbacks.Create().Before("gorm:create").Register, callbacks.Create().After("gorm:create").Register},
+		{"query", callbacks.Query().Before("gorm:query").Register, callbacks.Query().After("gorm:query").Register},
+		{"update", callbacks.Update().Before("gorm:update").Register, callbacks.Update().After("gorm:update").Register},
+		{"delete", callbacks.Delete().Before("gorm:delete").Register, callbacks.Delete().After("gorm:delete").Register},
+		{"row", callbacks.Row().Before("gorm:row").Register, callbacks.Row().After("gorm:row").Register},
+		{"raw", callbacks.Raw().Before("gorm:raw").Register, callbacks.Raw().After("gorm:raw").Register},
+	}
+
+	for _, p := range processors {
+		operation := p.operation
+		if err := p.before(prefix+":before_"+operation, func(db *gorm.DB) {
+			before(db, operation)
+		}); err != nil {
+			return err
+		}
+		if err := p.after(prefix+":after_"+operation, func(db *gorm.DB) {
+			after(db, operation)
+		}); err != nil {
+			return err
+		}
+	}
+	return nil
+}
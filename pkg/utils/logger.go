@@ -1,20 +1,71 @@
 package utils
 
 import (
+	"context"
+	"fmt"
 	"os"
+	"strings"
 
+	"github.com/PharmaKart/reminder-svc/pkg/config"
+	"github.com/PharmaKart/reminder-svc/pkg/redact"
 	"github.com/sirupsen/logrus"
+	"go.opentelemetry.io/otel/trace"
 )
 
 var Logger *logrus.Logger
 
-func InitLogger() {
+func init() {
+	// Usable before InitLogger runs, e.g. while loading config
 	Logger = logrus.New()
-	Logger.SetLevel(logrus.DebugLevel)
-	Logger.SetFormatter(&logrus.JSONFormatter{
-		PrettyPrint: true,
-	})
+	Logger.SetFormatter(&logrus.JSONFormatter{})
 	Logger.SetOutput(os.Stdout)
+	Logger.AddHook(redactHook{})
+}
+
+// InitLogger applies LOG_LEVEL and LOG_FORMAT ("json", "pretty" or "text")
+func InitLogger(cfg *config.Config) error {
+	level, err := logrus.ParseLevel(cfg.LOG_LEVEL)
+	if err != nil {
+		return fmt.Errorf("invalid log level %q: %w", cfg.LOG_LEVEL, err)
+	}
+	Logger.SetLevel(level)
+
+	switch strings.ToLower(cfg.LOG_FORMAT) {
+	case "json":
+		Logger.SetFormatter(&logrus.JSONFormatter{})
+	case "pretty":
+		Logger.SetFormatter(&logrus.JSONFormatter{
+			PrettyPrint: true,
+		})
+	case "text":
+		Logger.SetFormatter(&logrus.TextFormatter{
+			FullTimestamp: true,
+		})
+	default:
+		return fmt.Errorf("invalid log format %q", cfg.LOG_FORMAT)
+	}
+	return nil
+}
+
+// redactHook masks contact details in every log line, so a stray email address
+// or phone number in a field or an error never reaches the logs
+type redactHook struct{}
+
+func (redactHook) Levels() []logrus.Level {
+	return logrus.AllLevels
+}
+
+func (redactHook) Fire(entry *logrus.Entry) error {
+	entry.Message = redact.Text(entry.Message)
+	for key, value := range entry.Data {
+		switch v := value.(type) {
+		case string:
+			entry.Data[key] = redact.Field(key, v)
+		case error:
+			entry.Data[key] = redact.Field(key, v.Error())
+		}
+	}
+	return nil
 }
 
 func Info(message string, fields map[string]interface{}) {
@@ -28,3 +79,50 @@ func Warn(message string, fields map[string]interface{}) {
 func Error(message string, fields map[string]interface{}) {
 	Logger.WithFields(fields).Error(message)
 }
+
+// Fatal logs the message and exits
+func Fatal(message string, fields map[string]interface{}) {
+	Logger.WithFields(fields).Fatal(message)
+}
+
+type logFieldsKey struct{}
+
+// ContextWithLogFields returns a context whose log lines carry fields, on top
+// of any fields ctx already carries
+func ContextWithLogFields(ctx context.Context, fields map[string]interface{}) context.Context {
+	merged := make(logrus.Fields)
+	if existing, ok := ctx.Value(logFieldsKey{}).(logrus.Fields); ok {
+		for k, v := range existing {
+			merged[k] = v
+		}
+	}
+	for k, v := range fields {
+		merged[k] = v
+	}
+	return context.WithValue(ctx, logFieldsKey{}, merged)
+}
+
+// LoggerFromContext returns a logger carrying the request fields and trace ID
+// stored in ctx
+func LoggerFromContext(ctx context.Context) *logrus.Entry {
+	entry := logrus.NewEntry(Logger)
+	if fields, ok := ctx.Value(logFieldsKey{}).(logrus.Fields); ok {
+		entry = entry.WithFields(fields)
+	}
+	if spanContext := trace.SpanContextFromContext(ctx); spanContext.HasTraceID() {
+		entry = entry.WithField("trace_id", spanContext.TraceID().String())
+	}
+	return entry
+}
+
+func InfoContext(ctx context.Context, message string, fields map[string]interface{}) {
+	LoggerFromContext(ctx).WithFields(fields).Info(message)
+}
+
+func WarnContext(ctx context.Context, message string, fields map[string]interface{}) {
+	LoggerFromContext(ctx).WithFields(fields).Warn(message)
+}
+
+func ErrorContext(ctx context.Context, message string, fields map[string]interface{}) {
+	LoggerFromContext(ctx).WithFields(fields).Error(message)
+}